@@ -1,22 +1,51 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"math/rand"
-	"time"
 
 	"run-boy-run/game"
+	"run-boy-run/levels"
+	"run-boy-run/replay"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
-	ebiten.SetWindowSize(game.ScreenWidth, game.ScreenHeight)
-	ebiten.SetWindowTitle("ROAD ADVENTURE")
+	seed := flag.Int64("seed", 0, "seed for every run (0 - random)")
+	record := flag.String("record", "", "save a replay of every finished run to this file")
+	replayPath := flag.String("replay", "", "play back a replay file instead of reading the keyboard")
+	levelsDir := flag.String("levels", "", "directory with level files that add to or replace the built-in ones")
+	assetsDir := flag.String("assets", "", "directory with sprites that replace the built-in ones")
+	hotReload := flag.Bool("hot-reload", false, "reload sprites from -assets when the files change")
+	flag.Parse()
+
+	lv, err := levels.Load(*levelsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *hotReload && *assetsDir == "" {
+		log.Fatal("-hot-reload needs -assets")
+	}
 
-	rand.Seed(time.Now().UnixNano())
+	opts := game.Options{Levels: lv, Seed: *seed, Record: *record, Assets: *assetsDir, HotReload: *hotReload}
+	if *replayPath != "" {
+		rep, err := replay.Load(*replayPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Replay = rep
+	}
+
+	g, err := game.NewGame(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ebiten.SetWindowTitle("ROAD ADVENTURE")
 
-	if err := ebiten.RunGame(game.NewGame()); err != nil {
+	if err := ebiten.RunGame(g); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"fmt"
+
+	"run-boy-run/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Clip - анимация из кадров листа спрайтов. Время считается в шагах
+// симуляции, поэтому в реплее анимация идёт так же, как в игре
+type Clip struct {
+	Frames    []*ebiten.Image
+	Durations []int // Шагов на каждый кадр
+	Loop      bool  // Иначе после конца остаётся последний кадр
+	total     int
+}
+
+func newClip(frames []*ebiten.Image, durations []int, loop bool) *Clip {
+	c := &Clip{Frames: frames, Durations: durations, Loop: loop}
+	for _, d := range durations {
+		c.total += d
+	}
+	return c
+}
+
+// Frame возвращает кадр через tick шагов от начала анимации
+func (c *Clip) Frame(tick int) *ebiten.Image {
+	if c.Loop {
+		tick %= c.total
+	}
+	for i, d := range c.Durations {
+		if tick < d {
+			return c.Frames[i]
+		}
+		tick -= d
+	}
+	return c.Frames[len(c.Frames)-1]
+}
+
+// Done сообщает, закончилась ли однократная анимация
+func (c *Clip) Done(tick int) bool {
+	return !c.Loop && tick >= c.total
+}
+
+// animationDef - анимация в манифесте: кадры идут подряд вправо от rect
+type animationDef struct {
+	Name       string  `json:"name"`
+	Path       string  `json:"path"`
+	Rect       *[4]int `json:"rect"` // Первый кадр: x, y, ширина, высота
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Frames     int     `json:"frames"`
+	FrameTicks int     `json:"frame_ticks"`         // Шагов на кадр
+	Durations  []int   `json:"durations,omitempty"` // Или своя длительность каждого кадра
+	Loop       bool    `json:"loop"`
+}
+
+// Кадры анимации как отдельные спрайты; они пакуются в атлас вместе с остальными
+func (d animationDef) frameSprites() ([]spriteDef, error) {
+	if d.Rect == nil || d.Frames <= 0 {
+		return nil, fmt.Errorf("animation %s needs rect and a positive frame count", d.Name)
+	}
+	var defs []spriteDef
+	for i := 0; i < d.Frames; i++ {
+		r := *d.Rect
+		r[0] += i * r[2]
+		defs = append(defs, spriteDef{
+			Name:   frameName(d.Name, i),
+			Path:   d.Path,
+			Width:  d.Width,
+			Height: d.Height,
+			Rect:   &r,
+		})
+	}
+	return defs, nil
+}
+
+func (d animationDef) durations() ([]int, error) {
+	if d.Durations == nil {
+		if d.FrameTicks <= 0 {
+			return nil, fmt.Errorf("animation %s needs frame_ticks or durations", d.Name)
+		}
+		durations := make([]int, d.Frames)
+		for i := range durations {
+			durations[i] = d.FrameTicks
+		}
+		return durations, nil
+	}
+	if len(d.Durations) != d.Frames {
+		return nil, fmt.Errorf("animation %s has %d frames but %d durations", d.Name, d.Frames, len(d.Durations))
+	}
+	for _, t := range d.Durations {
+		if t <= 0 {
+			return nil, fmt.Errorf("animation %s has a non-positive duration", d.Name)
+		}
+	}
+	return d.Durations, nil
+}
+
+func frameName(clip string, i int) string {
+	return fmt.Sprintf("%s#%d", clip, i)
+}
+
+// Анимации, без которых игра не запускается
+var requiredClips = []string{"player_walk_up", "player_walk_down", "player_walk_left", "player_walk_right", "player_hit"}
+
+// Анимации ходьбы по направлению взгляда игрока
+var walkClips = [...]string{
+	sim.FaceUp:    "player_walk_up",
+	sim.FaceDown:  "player_walk_down",
+	sim.FaceLeft:  "player_walk_left",
+	sim.FaceRight: "player_walk_right",
+}
+
+// effect - однократная анимация на месте события, например удара
+type effect struct {
+	clip  *Clip
+	x, y  float64
+	start int  // Шаг эффектов, на котором она началась
+	hold  bool // После конца остаётся на последнем кадре
+}
+
+func (g *Game) addEffect(name string, x, y float64, hold bool) {
+	if clip := g.assets.Clip(name); clip != nil {
+		g.effects = append(g.effects, effect{clip: clip, x: x, y: y, start: g.effectTick, hold: hold})
+	}
+}
+
+func (g *Game) updateEffects() {
+	alive := g.effects[:0]
+	for _, e := range g.effects {
+		if e.hold || !e.clip.Done(g.effectTick-e.start) {
+			alive = append(alive, e)
+		}
+	}
+	g.effects = alive
+}
+
+// tickEffects продвигает эффекты, когда забег окончен и мир больше не шагает:
+// удар доигрывает поверх экрана итогов с той же скоростью, что и в забеге
+func (g *Game) tickEffects() {
+	g.effectTick += g.elapsedTicks()
+	g.updateEffects()
+}
+
+func (g *Game) drawEffects(screen *ebiten.Image) {
+	for _, e := range g.effects {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(e.x, e.y)
+		screen.DrawImage(e.clip.Frame(g.effectTick-e.start), op)
+	}
+}
+
+// Кадр игрока: на месте - первый кадр ходьбы, в движении - цикл по шагам мира
+func (g *Game) playerFrame() *ebiten.Image {
+	clip := g.assets.Clip(walkClips[g.world.Facing])
+	if !g.world.Walking {
+		return clip.Frames[0]
+	}
+	return clip.Frame(g.world.Tick)
+}
+
+// Кадр машины или плота; без анимации - обычный спрайт
+func (g *Game) vehicleFrame(obj *sim.GameObject) *ebiten.Image {
+	if clip := g.assets.Clip(obj.Vehicle); clip != nil {
+		return clip.Frame(g.world.Tick)
+	}
+	return g.assets.Sprite(obj.Vehicle)
+}
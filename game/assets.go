@@ -0,0 +1,368 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "image/png"
+
+	sprites "run-boy-run/image"
+	"run-boy-run/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const manifestFile = "manifest.json"
+
+// Как часто проверять изменения файлов при горячей перезагрузке
+const reloadInterval = time.Second
+
+// spriteDef - запись манифеста
+type spriteDef struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Width    int     `json:"width"` // Размер в игре; 0 - размер исходной области
+	Height   int     `json:"height"`
+	Rect     *[4]int `json:"rect,omitempty"` // Область файла: x, y, ширина, высота; нет - весь файл
+	Optional bool    `json:"optional,omitempty"`
+}
+
+type manifest struct {
+	Sprites    []spriteDef    `json:"sprites"`
+	Animations []animationDef `json:"animations"`
+}
+
+// Assets - спрайты по именам из манифеста, упакованные в атласы.
+// С каталогом dir файлы из него заменяют встроенные и могут
+// перезагружаться на ходу
+type Assets struct {
+	dir       string
+	fsys      fs.FS
+	sprites   map[string]*ebiten.Image
+	clips     map[string]*Clip
+	atlases   []*ebiten.Image
+	stamps    map[string]time.Time // Время изменения файлов из dir
+	lastCheck time.Time
+}
+
+// LoadAssets загружает спрайты. Все ошибки манифеста и файлов сообщаются сразу
+func LoadAssets(dir string) (*Assets, error) {
+	a := &Assets{dir: dir, fsys: sprites.FS(dir)}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Sprite возвращает спрайт или nil, если необязательного спрайта нет
+func (a *Assets) Sprite(name string) *ebiten.Image {
+	return a.sprites[name]
+}
+
+// Clip возвращает анимацию или nil
+func (a *Assets) Clip(name string) *Clip {
+	return a.clips[name]
+}
+
+// Спрайты, без которых игра не запускается
+func requiredSprites() []string {
+	names := []string{"player"}
+	for name := range sim.Vehicles {
+		names = append(names, name)
+	}
+	for t := sim.Tile(0); t < sim.TileCount; t++ {
+		names = append(names, tileSprite(t))
+	}
+	return names
+}
+
+func tileSprite(t sim.Tile) string {
+	return "tile_" + t.String()
+}
+
+func (a *Assets) load() error {
+	m, err := a.readManifest()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	// Кадры анимаций загружаются как обычные спрайты
+	all := m.Sprites
+	durations := map[string][]int{}
+	for _, anim := range m.Animations {
+		frames, err := anim.frameSprites()
+		if err == nil {
+			durations[anim.Name], err = anim.durations()
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		all = append(all, frames...)
+	}
+
+	var defs []spriteDef
+	var images []*ebiten.Image
+	// Каждый файл загружается в видеопамять один раз, сколько бы спрайтов
+	// из него ни вырезалось, и освобождается после упаковки в атласы
+	sources := map[string]*ebiten.Image{}
+	decoded := map[string]image.Image{}
+	defer func() {
+		for _, src := range sources {
+			src.Deallocate()
+		}
+	}()
+	seen := map[string]bool{}
+	for i := range all {
+		def := &all[i]
+		seen[def.Name] = true
+		if err := def.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sprite %s: %w", def.Name, err))
+			continue
+		}
+		src, ok := sources[def.Path]
+		if !ok {
+			img, err := decodeImage(a.fsys, def.Path)
+			if err != nil {
+				if !def.Optional {
+					errs = append(errs, fmt.Errorf("sprite %s: %w", def.Name, err))
+				}
+				continue
+			}
+			src = ebiten.NewImageFromImage(img)
+			sources[def.Path] = src
+			decoded[def.Path] = img
+		}
+		img, err := cutSprite(src, def)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sprite %s: %w", def.Name, err))
+			continue
+		}
+		a.checkMask(def, decoded[def.Path])
+		defs = append(defs, *def)
+		images = append(images, img)
+	}
+
+	for _, name := range requiredSprites() {
+		if !seen[name] {
+			errs = append(errs, fmt.Errorf("sprite %s is not in the manifest", name))
+		}
+	}
+	for _, name := range requiredClips {
+		if _, ok := durations[name]; !ok {
+			errs = append(errs, fmt.Errorf("animation %s is not in the manifest", name))
+		}
+	}
+	for _, def := range defs {
+		if v, ok := sim.Vehicles[def.Name]; ok && (def.Width != v.Width || def.Height != v.Height) {
+			errs = append(errs, fmt.Errorf("sprite %s is %dx%d, but the vehicle is %dx%d", def.Name, def.Width, def.Height, v.Width, v.Height))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		for _, img := range images {
+			img.Deallocate()
+		}
+		return err
+	}
+
+	a.pack(defs, images)
+	a.clips = map[string]*Clip{}
+	for _, anim := range m.Animations {
+		frames := make([]*ebiten.Image, anim.Frames)
+		for i := range frames {
+			frames[i] = a.sprites[frameName(anim.Name, i)]
+		}
+		a.clips[anim.Name] = newClip(frames, durations[anim.Name], anim.Loop)
+	}
+	a.stamps = a.fileStamps(m)
+	return nil
+}
+
+func (a *Assets) readManifest() (*manifest, error) {
+	data, err := fs.ReadFile(a.fsys, manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestFile, err)
+	}
+	return &m, nil
+}
+
+func decodeImage(fsys fs.FS, name string) (image.Image, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("missing asset %s: %w", name, err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("asset %s: %w", name, err)
+	}
+	return img, nil
+}
+
+// validate проверяет размеры до загрузки: картинка неположительного
+// размера роняет ebiten.NewImage, а манифест из -assets может быть любым
+func (d *spriteDef) validate() error {
+	if d.Width < 0 || d.Height < 0 || (d.Width == 0) != (d.Height == 0) {
+		return fmt.Errorf("size %dx%d must be positive, or 0x0 for the size of the file area", d.Width, d.Height)
+	}
+	if d.Rect != nil && (d.Rect[2] <= 0 || d.Rect[3] <= 0) {
+		return fmt.Errorf("rect %v must have a positive width and height", *d.Rect)
+	}
+	return nil
+}
+
+// Вырезает область спрайта и масштабирует её до размера из манифеста
+func cutSprite(src *ebiten.Image, def *spriteDef) (*ebiten.Image, error) {
+	area := src.Bounds()
+	if def.Rect != nil {
+		r := def.Rect
+		area = image.Rect(r[0], r[1], r[0]+r[2], r[1]+r[3]).Add(src.Bounds().Min)
+		if !area.In(src.Bounds()) {
+			return nil, fmt.Errorf("rect %v is outside %s (%dx%d)", *r, def.Path, src.Bounds().Dx(), src.Bounds().Dy())
+		}
+	}
+	if def.Width == 0 || def.Height == 0 {
+		def.Width, def.Height = area.Dx(), area.Dy()
+	}
+
+	part := src.SubImage(area).(*ebiten.Image)
+	img := ebiten.NewImage(def.Width, def.Height)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(def.Width)/float64(area.Dx()), float64(def.Height)/float64(area.Dy()))
+	img.DrawImage(part, op)
+	return img, nil
+}
+
+// checkMask предупреждает, если спрайт транспорта из dir непрозрачен не там,
+// где встроенный: столкновения считаются по маске встроенного спрайта,
+// и игрок увидит удар там, где машины не видно, или наоборот
+func (a *Assets) checkMask(def *spriteDef, src image.Image) {
+	if a.dir == "" {
+		return
+	}
+	v, ok := sim.Vehicles[def.Name]
+	if !ok || v.Shape.Kind != sim.ShapeMask {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(a.dir, def.Path)); err != nil {
+		return // Встроенный файл
+	}
+	if def.Rect != nil {
+		sub, ok := src.(interface {
+			SubImage(image.Rectangle) image.Image
+		})
+		if !ok {
+			return
+		}
+		r := def.Rect
+		src = sub.SubImage(image.Rect(r[0], r[1], r[0]+r[2], r[1]+r[3]).Add(src.Bounds().Min))
+	}
+	mask := sim.NewAlphaMask(src, v.Width, v.Height)
+	for y := 0; y < v.Height; y++ {
+		for x := 0; x < v.Width; x++ {
+			if mask.Solid(x, y) != v.Shape.Mask.Solid(x, y) {
+				log.Printf("Sprite %s from %s has a different outline than the built-in one; collisions still use the built-in outline", def.Name, def.Path)
+				return
+			}
+		}
+	}
+}
+
+// Спрайты переносятся в атласы, временные картинки освобождаются
+func (a *Assets) pack(defs []spriteDef, images []*ebiten.Image) {
+	sizes := make([]image.Point, len(images))
+	for i, img := range images {
+		sizes[i] = img.Bounds().Size()
+	}
+	pages, places, pageSizes := packAtlas(sizes)
+
+	atlases := make([]*ebiten.Image, len(pageSizes))
+	for i, size := range pageSizes {
+		atlases[i] = ebiten.NewImage(size.X, size.Y)
+	}
+	spritesByName := make(map[string]*ebiten.Image, len(defs))
+	for i, img := range images {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(places[i].X), float64(places[i].Y))
+		atlases[pages[i]].DrawImage(img, op)
+		img.Deallocate()
+		rect := image.Rectangle{Min: places[i], Max: places[i].Add(sizes[i])}
+		spritesByName[defs[i].Name] = atlases[pages[i]].SubImage(rect).(*ebiten.Image)
+	}
+
+	for _, old := range a.atlases {
+		old.Deallocate()
+	}
+	a.atlases = atlases
+	a.sprites = spritesByName
+}
+
+// Время изменения манифеста и файлов спрайтов в каталоге dir
+func (a *Assets) fileStamps(m *manifest) map[string]time.Time {
+	if a.dir == "" {
+		return nil
+	}
+	stamps := map[string]time.Time{}
+	for _, name := range append([]string{manifestFile}, manifestPaths(m)...) {
+		if info, err := os.Stat(filepath.Join(a.dir, name)); err == nil {
+			stamps[name] = info.ModTime()
+		}
+	}
+	return stamps
+}
+
+func manifestPaths(m *manifest) []string {
+	var paths []string
+	for _, def := range m.Sprites {
+		paths = append(paths, def.Path)
+	}
+	for _, anim := range m.Animations {
+		paths = append(paths, anim.Path)
+	}
+	return paths
+}
+
+// CheckReload перезагружает спрайты, если файлы в каталоге изменились.
+// При ошибке остаются прежние спрайты, чтобы опечатка не роняла игру
+func (a *Assets) CheckReload() {
+	if a.dir == "" || time.Since(a.lastCheck) < reloadInterval {
+		return
+	}
+	a.lastCheck = time.Now()
+
+	m, err := a.readManifest()
+	if err != nil {
+		return
+	}
+	stamps := a.fileStamps(m)
+	changed := len(stamps) != len(a.stamps)
+	for name, t := range stamps {
+		if !a.stamps[name].Equal(t) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := a.load(); err != nil {
+		a.stamps = stamps // Ждём следующего изменения, а не повторяем ошибку каждую секунду
+		log.Printf("Failed to reload assets: %v", err)
+		return
+	}
+	log.Printf("Reloaded assets from %s", a.dir)
+}
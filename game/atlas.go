@@ -0,0 +1,69 @@
+package game
+
+import (
+	"image"
+	"sort"
+)
+
+// Размер страницы атласа; спрайт крупнее получает страницу своего размера
+const atlasSize = 1024
+
+// atlasPage - страница атласа, заполняемая полками: спрайты встают слева
+// направо, а когда полка кончается, начинается новая под ней
+type atlasPage struct {
+	width, height int
+	shelfY        int // Верх текущей полки
+	shelfHeight   int
+	x             int // Где начнётся следующий спрайт на полке
+}
+
+func (p *atlasPage) place(w, h int) (image.Point, bool) {
+	if p.x+w > p.width {
+		p.shelfY += p.shelfHeight
+		p.x, p.shelfHeight = 0, 0
+	}
+	if p.x+w > p.width || p.shelfY+h > p.height {
+		return image.Point{}, false
+	}
+	pos := image.Pt(p.x, p.shelfY)
+	p.x += w
+	p.shelfHeight = max(p.shelfHeight, h)
+	return pos, true
+}
+
+// packAtlas раскладывает прямоугольники размеров sizes по страницам.
+// Возвращает номер страницы и место каждого прямоугольника и размеры страниц
+func packAtlas(sizes []image.Point) (pages []int, places []image.Point, pageSizes []image.Point) {
+	// Высокие спрайты первыми - полки получаются плотнее
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]].Y > sizes[order[b]].Y })
+
+	pages = make([]int, len(sizes))
+	places = make([]image.Point, len(sizes))
+	var open []*atlasPage
+	for _, i := range order {
+		w, h := sizes[i].X, sizes[i].Y
+		placed := false
+		for n, p := range open {
+			if pos, ok := p.place(w, h); ok {
+				pages[i], places[i], placed = n, pos, true
+				break
+			}
+		}
+		if !placed {
+			p := &atlasPage{width: max(atlasSize, w), height: max(atlasSize, h)}
+			pos, _ := p.place(w, h)
+			open = append(open, p)
+			pages[i], places[i] = len(open)-1, pos
+		}
+	}
+
+	for _, p := range open {
+		// Страница обрезается по занятой высоте
+		pageSizes = append(pageSizes, image.Pt(p.width, min(p.height, p.shelfY+p.shelfHeight)))
+	}
+	return pages, places, pageSizes
+}
@@ -0,0 +1,209 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"run-boy-run/sim"
+	"run-boy-run/sound"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const sampleRate = 44100
+
+// Музыка для каждого состояния игры
+const (
+	musicMenu  = "music_menu"
+	musicPlay  = "music_play"
+	musicPause = "music_pause"
+)
+
+// Звуковые эффекты
+const (
+	sfxStep   = "step"
+	sfxHorn   = "horn"
+	sfxCrash  = "crash"
+	sfxWin    = "win"
+	sfxTick   = "tick"
+	sfxTimeUp = "timeup"
+)
+
+var musicTracks = []string{musicMenu, musicPlay, musicPause}
+
+var soundEffects = []string{sfxStep, sfxHorn, sfxCrash, sfxWin, sfxTick, sfxTimeUp}
+
+const (
+	footstepTicks = 15 // Шаг звучит раз в столько шагов мира, пока игрок идёт
+	lowTime       = 5  // Последние секунды отсчитываются тиканьем
+)
+
+// AudioSettings - громкость музыки и эффектов от 0 до 1
+type AudioSettings struct {
+	MusicVolume float64 `json:"music_volume"`
+	SFXVolume   float64 `json:"sfx_volume"`
+	Muted       bool    `json:"muted"`
+}
+
+func DefaultAudioSettings() AudioSettings {
+	return AudioSettings{MusicVolume: 0.5, SFXVolume: 0.8}
+}
+
+func clampVolume(v float64) float64 {
+	return max(0, min(1, v))
+}
+
+// Audio играет музыку текущего экрана и звуковые эффекты
+type Audio struct {
+	ctx      *audio.Context
+	music    map[string]*audio.Player // Зацикленные дорожки; на паузе помнят место
+	sfx      map[string][]byte        // Уже декодированные эффекты
+	current  string
+	settings AudioSettings
+}
+
+func NewAudio(settings AudioSettings) (*Audio, error) {
+	a := &Audio{
+		ctx:      audio.NewContext(sampleRate),
+		music:    make(map[string]*audio.Player),
+		sfx:      make(map[string][]byte),
+		settings: settings,
+	}
+	var errs []error
+	for _, name := range musicTracks {
+		stream, err := decodeSound(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p, err := a.ctx.NewPlayer(audio.NewInfiniteLoop(stream, stream.Length()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		a.music[name] = p
+	}
+	for _, name := range soundEffects {
+		stream, err := decodeSound(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data, err := io.ReadAll(stream)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		a.sfx[name] = data
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to load sounds:\n%w", err)
+	}
+	a.applyVolume()
+	return a, nil
+}
+
+func decodeSound(name string) (*wav.Stream, error) {
+	data, err := sound.Read(name + ".wav")
+	if err != nil {
+		return nil, err
+	}
+	stream, err := wav.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stream, nil
+}
+
+// PlayMusic переключает музыку; та же дорожка продолжает играть
+func (a *Audio) PlayMusic(name string) {
+	if name == a.current {
+		return
+	}
+	if p := a.music[a.current]; p != nil {
+		p.Pause()
+	}
+	a.current = name
+	if p := a.music[name]; p != nil {
+		p.Play()
+	}
+}
+
+// Play запускает звуковой эффект; одинаковые эффекты могут звучать одновременно
+func (a *Audio) Play(name string) {
+	data, ok := a.sfx[name]
+	if !ok || a.sfxVolume() == 0 {
+		return
+	}
+	p := a.ctx.NewPlayerFromBytes(data)
+	p.SetVolume(a.sfxVolume())
+	p.Play()
+}
+
+func (a *Audio) Settings() AudioSettings {
+	return a.settings
+}
+
+// SetSettings меняет громкость сразу, в том числе у играющей музыки
+func (a *Audio) SetSettings(s AudioSettings) {
+	s.MusicVolume = clampVolume(s.MusicVolume)
+	s.SFXVolume = clampVolume(s.SFXVolume)
+	a.settings = s
+	a.applyVolume()
+}
+
+func (a *Audio) ToggleMute() {
+	s := a.settings
+	s.Muted = !s.Muted
+	a.SetSettings(s)
+}
+
+func (a *Audio) Muted() bool {
+	return a.settings.Muted
+}
+
+func (a *Audio) applyVolume() {
+	v := a.settings.MusicVolume
+	if a.settings.Muted {
+		v = 0
+	}
+	for _, p := range a.music {
+		p.SetVolume(v)
+	}
+}
+
+func (a *Audio) sfxVolume() float64 {
+	if a.settings.Muted {
+		return 0
+	}
+	return a.settings.SFXVolume
+}
+
+// Музыка для экрана: своя в игре и на паузе, остальные экраны - меню
+func sceneMusic(id SceneID) string {
+	switch id {
+	case ScenePlaying:
+		return musicPlay
+	case ScenePaused:
+		return musicPause
+	default:
+		return musicMenu
+	}
+}
+
+// Шаги игрока и отсчёт последних секунд; вызывается после каждого шага мира
+func (g *Game) playStepSounds() {
+	w := g.world
+	if w.Outcome != sim.Running {
+		return
+	}
+	if w.Walking && w.Tick%footstepTicks == 0 {
+		g.audio.Play(sfxStep)
+	}
+	if w.Tick%sim.TickRate == 0 && w.CurrentTime <= lowTime {
+		g.audio.Play(sfxTick)
+	}
+}
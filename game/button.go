@@ -13,6 +13,8 @@ type Button struct {
 	X, Y, Width, Height float64
 	Text                string
 	Hovered             bool
+	Focused             bool // Выбрана мышью или с клавиатуры
+	Disabled            bool // Видна, но не нажимается
 	Action              func()
 	Font                font.Face
 }
@@ -24,9 +26,12 @@ func (b *Button) Contains(x, y float64) bool {
 func (b *Button) Draw(screen *ebiten.Image) {
 	// Цвет кнопки - градиент от синего к более светлому
 	btnColor := color.RGBA{65, 105, 225, 255} // Royal Blue
-	if b.Hovered {
+	if b.Focused {
 		btnColor = color.RGBA{100, 149, 237, 255} // Cornflower Blue
 	}
+	if b.Disabled {
+		btnColor = color.RGBA{90, 90, 90, 255}
+	}
 
 	// Основной прямоугольник кнопки
 	vector.DrawFilledRect(screen, float32(b.X), float32(b.Y), float32(b.Width), float32(b.Height), btnColor, false)
@@ -36,7 +41,7 @@ func (b *Button) Draw(screen *ebiten.Image) {
 	vector.StrokeRect(screen, float32(b.X), float32(b.Y), float32(b.Width), float32(b.Height), 1, borderColor, false)
 	
 	// Эффект тени при наведении
-	if b.Hovered {
+	if b.Focused {
 		shadowColor := color.RGBA{255, 255, 255, 50}
 		vector.DrawFilledRect(screen, float32(b.X)+2, float32(b.Y)+2, float32(b.Width), float32(b.Height), shadowColor, false)
 	}
@@ -47,7 +52,7 @@ func (b *Button) Draw(screen *ebiten.Image) {
 	textColor := color.White
 	
 	// Полужирный эффект для текста при наведении
-	if b.Hovered {
+	if b.Focused {
 		textColor = color.Black 
 	}
 	
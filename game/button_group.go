@@ -0,0 +1,93 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// ButtonGroup - кнопки одного экрана с фокусом: мышь, Tab и направления
+// переводят фокус, действие Confirm нажимает кнопку в фокусе
+type ButtonGroup struct {
+	Buttons  []*Button
+	controls *Controls
+	focus    int
+	mouseX   int
+	mouseY   int
+}
+
+func NewButtonGroup(controls *Controls, buttons ...*Button) *ButtonGroup {
+	m := &ButtonGroup{
+		Buttons:  buttons,
+		controls: controls,
+	}
+	m.mouseX, m.mouseY = controls.Cursor()
+	m.Focus(0)
+	return m
+}
+
+// Focus переводит фокус на кнопку с индексом i
+func (m *ButtonGroup) Focus(i int) {
+	if len(m.Buttons) == 0 {
+		return
+	}
+	m.focus = (i%len(m.Buttons) + len(m.Buttons)) % len(m.Buttons)
+	for j, b := range m.Buttons {
+		b.Focused = j == m.focus
+	}
+}
+
+func (m *ButtonGroup) Focused() *Button {
+	if len(m.Buttons) == 0 {
+		return nil
+	}
+	return m.Buttons[m.focus]
+}
+
+func (m *ButtonGroup) Update() {
+	// Мышь: фокус следует за курсором, только когда он двигается,
+	// чтобы не перебивать выбор с клавиатуры
+	mx, my := m.controls.Cursor()
+	moved := mx != m.mouseX || my != m.mouseY
+	m.mouseX, m.mouseY = mx, my
+
+	for i, b := range m.Buttons {
+		b.Hovered = b.Contains(float64(mx), float64(my))
+		if b.Hovered && moved {
+			m.Focus(i)
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		for _, b := range m.Buttons {
+			if b.Hovered && !b.Disabled {
+				b.Action()
+				return
+			}
+		}
+	}
+
+	// Клавиатура и геймпад
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab) && shift,
+		m.controls.JustPressed(ActionLeft),
+		m.controls.JustPressed(ActionUp):
+		m.Focus(m.focus - 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab),
+		m.controls.JustPressed(ActionRight),
+		m.controls.JustPressed(ActionDown):
+		m.Focus(m.focus + 1)
+	}
+
+	if m.controls.JustPressed(ActionConfirm) {
+		if b := m.Focused(); b != nil && !b.Disabled {
+			b.Action()
+		}
+	}
+}
+
+func (m *ButtonGroup) Draw(screen *ebiten.Image) {
+	for _, b := range m.Buttons {
+		b.Draw(screen)
+	}
+}
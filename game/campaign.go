@@ -0,0 +1,222 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+
+	"run-boy-run/sim"
+	"run-boy-run/storage"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const progressFile = "progress.json"
+
+// Сохранённый прогресс кампании
+type campaignProgress struct {
+	Unlocked  int `json:"unlocked"`   // Сколько уровней кампании открыто
+	BestTotal int `json:"best_total"` // Лучший счёт за всю кампанию
+}
+
+func loadCampaignProgress() campaignProgress {
+	p := campaignProgress{Unlocked: 1}
+	if err := storage.Load(progressFile, &p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to load campaign progress: %v, starting over", err)
+	}
+	if p.Unlocked < 1 {
+		p.Unlocked = 1
+	}
+	return p
+}
+
+// Текущее прохождение кампании
+type campaignRun struct {
+	index int // Номер уровня в Levels.Campaign
+	total int // Счёт за пройденные уровни
+	last  int // Счёт последнего пройденного уровня
+}
+
+func (c *campaignRun) level(g *Game) *sim.Level {
+	return g.options.Levels.Campaign[c.index]
+}
+
+func (c *campaignRun) finished(g *Game) bool {
+	return c.index == len(g.options.Levels.Campaign)-1
+}
+
+// Начало кампании с уровня index; счёт копится с этого уровня
+func (g *Game) startCampaign(index int) {
+	g.campaign = &campaignRun{index: index}
+	g.startLevel(g.campaign.level(g))
+	g.switchScene(ScenePlaying)
+}
+
+// Уровень пройден: копим счёт и открываем следующий
+func (g *Game) completeCampaignLevel() {
+	c := g.campaign
+	c.last = g.world.Score.Points
+	c.total += c.last
+
+	if next := c.index + 2; next > g.progress.Unlocked {
+		g.progress.Unlocked = min(next, len(g.options.Levels.Campaign))
+	}
+	if c.finished(g) && c.total > g.progress.BestTotal {
+		g.progress.BestTotal = c.total
+	}
+	if err := storage.Save(progressFile, g.progress); err != nil {
+		log.Printf("Failed to save campaign progress: %v", err)
+	}
+}
+
+// campaignScene - выбор уровня кампании
+type campaignScene struct {
+	g       *Game
+	buttons *ButtonGroup
+}
+
+func (s *campaignScene) ID() SceneID { return SceneCampaign }
+
+// Кнопки пересобираются при каждом входе, потому что открытых уровней могло стать больше
+func (s *campaignScene) Enter() {
+	g := s.g
+	var buttons []*Button
+	for i, l := range g.options.Levels.Campaign {
+		i := i
+		b := &Button{
+			X:      ScreenWidth/2 - 150,
+			Y:      float64(90 + i*45),
+			Width:  300,
+			Height: 35,
+			Text:   fmt.Sprintf("%d. %s", i+1, l.Name),
+			Font:   Font,
+			Action: func() { g.startCampaign(i) },
+		}
+		if i >= g.progress.Unlocked {
+			b.Text = fmt.Sprintf("%d. Locked", i+1)
+			b.Disabled = true
+		}
+		buttons = append(buttons, b)
+	}
+	buttons = append(buttons, &Button{
+		X:      ScreenWidth/2 - 100,
+		Y:      ScreenHeight - 80,
+		Width:  200,
+		Height: 40,
+		Text:   "Back",
+		Font:   Font,
+		Action: func() { g.switchScene(SceneMenu) },
+	})
+
+	s.buttons = NewButtonGroup(g.controls, buttons...)
+	// Фокус на последнем открытом уровне
+	s.buttons.Focus(min(g.progress.Unlocked, len(g.options.Levels.Campaign)) - 1)
+}
+
+func (s *campaignScene) Exit() {}
+
+func (s *campaignScene) Update() {
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	s.buttons.Update()
+}
+
+func (s *campaignScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	title := "CAMPAIGN"
+	titleBounds := text.BoundString(Font, title)
+	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 50, color.RGBA{255, 215, 0, 255})
+
+	if best := s.g.progress.BestTotal; best > 0 {
+		bestText := fmt.Sprintf("Best total: %d", best)
+		bestBounds := text.BoundString(Font, bestText)
+		text.Draw(screen, bestText, Font, ScreenWidth/2-bestBounds.Max.X/2, 72, color.RGBA{200, 200, 200, 255})
+	}
+
+	s.buttons.Draw(screen)
+}
+
+// levelCompleteScene - переход между уровнями кампании поверх игры
+type levelCompleteScene struct {
+	g       *Game
+	next    *Button
+	buttons *ButtonGroup
+}
+
+func newLevelCompleteScene(g *Game) *levelCompleteScene {
+	s := &levelCompleteScene{g: g}
+	s.next = &Button{
+		X:      ScreenWidth/2 - 100,
+		Y:      ScreenHeight/2 + 50,
+		Width:  200,
+		Height: 40,
+		Font:   Font,
+		Action: func() {
+			if g.campaign.finished(g) {
+				if g.highScores.Qualifies(campaignTable, g.campaign.total) {
+					g.askName(campaignTable, g.campaign.total, nil, SceneCampaign)
+					g.switchScene(SceneNameEntry)
+					return
+				}
+				g.switchScene(SceneCampaign)
+				return
+			}
+			g.campaign.index++
+			g.startLevel(g.campaign.level(g))
+			g.switchScene(ScenePlaying)
+		},
+	}
+	s.buttons = NewButtonGroup(g.controls, s.next, g.buttons["menu"])
+	return s
+}
+
+func (s *levelCompleteScene) ID() SceneID { return SceneLevelComplete }
+
+func (s *levelCompleteScene) Enter() {
+	s.g.finishRun()
+	s.g.completeCampaignLevel()
+
+	s.next.Text = "Next Level"
+	if s.g.campaign.finished(s.g) {
+		s.next.Text = "Campaign"
+	}
+	s.buttons.Focus(0)
+}
+
+func (s *levelCompleteScene) Exit() {}
+
+func (s *levelCompleteScene) Update() {
+	s.g.tickEffects()
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	s.buttons.Update()
+}
+
+func (s *levelCompleteScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 150}, false)
+
+	c := s.g.campaign
+	lines := []string{
+		"LEVEL COMPLETE!",
+		fmt.Sprintf("Level score: %d", c.last),
+		fmt.Sprintf("Total score: %d", c.total),
+	}
+	if c.finished(s.g) {
+		lines[0] = "CAMPAIGN COMPLETE!"
+	}
+	for i, line := range lines {
+		bounds := text.BoundString(Font, line)
+		text.Draw(screen, line, Font, ScreenWidth/2-bounds.Max.X/2, ScreenHeight/2-80+i*25, color.White)
+	}
+
+	s.buttons.Draw(screen)
+}
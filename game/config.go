@@ -0,0 +1,152 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+
+	"run-boy-run/sim"
+	"run-boy-run/storage"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Версия файла настроек; при изменении формата добавляется шаг в migrations
+const configVersion = 1
+
+const configFile = "config.json"
+
+// Файлы настроек до появления config.json (версия 0)
+const (
+	legacyBindingsFile = "bindings.json"
+	legacyAudioFile    = "audio.json"
+)
+
+const maxWindowScale = 3
+
+// Config - все настройки игрока, сохраняются в config.json
+type Config struct {
+	Version      int              `json:"version"`
+	WindowScale  int              `json:"window_scale"`
+	Fullscreen   bool             `json:"fullscreen"`
+	PixelPerfect bool             `json:"pixel_perfect"` // Только целый масштаб кадра
+	Audio        AudioSettings    `json:"audio"`
+	Bindings     Bindings         `json:"bindings"`
+	Mode         sim.MovementMode `json:"movement_mode"`
+	Palette      Palette          `json:"palette"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Version:      configVersion,
+		WindowScale:  1,
+		PixelPerfect: true,
+		Audio:        DefaultAudioSettings(),
+		Bindings:     DefaultBindings(),
+	}
+}
+
+// UnmarshalJSON разбирает перечисления по одному: значение, которого эта
+// версия не знает (например, палитра из более новой версии игры), остаётся
+// по умолчанию, а остальные настройки не теряются
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config // Без этого метода, иначе разбор зациклится
+	var raw struct {
+		*plain
+		Mode    json.RawMessage `json:"movement_mode"`
+		Palette json.RawMessage `json:"palette"`
+	}
+	raw.plain = (*plain)(c)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	decodeSetting("movement_mode", raw.Mode, &c.Mode)
+	decodeSetting("palette", raw.Palette, &c.Palette)
+	return nil
+}
+
+// decodeSetting разбирает одно значение; при ошибке v не меняется
+func decodeSetting(name string, data json.RawMessage, v any) {
+	if len(data) == 0 {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Printf("Ignoring setting %s: %v", name, err)
+	}
+}
+
+// migrations[v] переводит настройки версии v в версию v+1
+var migrations = [configVersion]func(*Config){
+	migrateLegacyFiles,
+}
+
+// LoadConfig читает настройки и обновляет старые версии до текущей.
+// Обновлённые настройки сразу сохраняются
+func LoadConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Version = 0 // Нет файла - настройки ещё в старых файлах
+	if err := storage.LoadOrQuarantine(configFile, "settings", &cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return DefaultConfig()
+	}
+
+	migrated := storage.Migrate("settings", &cfg, &cfg.Version, migrations[:])
+	cfg.normalize()
+
+	if migrated {
+		if err := storage.Save(configFile, cfg); err != nil {
+			log.Printf("Failed to save settings: %v", err)
+		}
+	}
+	return cfg
+}
+
+// 0 -> 1: привязки и громкость лежали в отдельных файлах
+func migrateLegacyFiles(cfg *Config) {
+	var bindings Bindings
+	if err := storage.Load(legacyBindingsFile, &bindings); err == nil {
+		for a, b := range bindings {
+			cfg.Bindings[a] = b
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to migrate key bindings: %v", err)
+	}
+	if err := storage.Load(legacyAudioFile, &cfg.Audio); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to migrate audio settings: %v", err)
+	}
+}
+
+// normalize исправляет значения, которые могли испортить правкой файла
+func (c *Config) normalize() {
+	c.WindowScale = max(1, min(maxWindowScale, c.WindowScale))
+	c.Audio.MusicVolume = clampVolume(c.Audio.MusicVolume)
+	c.Audio.SFXVolume = clampVolume(c.Audio.SFXVolume)
+	if c.Palette < 0 || c.Palette >= paletteCount {
+		c.Palette = PaletteDefault
+	}
+	if c.Bindings == nil {
+		c.Bindings = Bindings{}
+	}
+	for a, b := range DefaultBindings() {
+		if _, ok := c.Bindings[a]; !ok {
+			c.Bindings[a] = b
+		}
+	}
+}
+
+// saveConfig собирает текущие настройки игры и сохраняет их
+func (g *Game) saveConfig() {
+	g.config.Bindings = g.controls.Bindings
+	g.config.Audio = g.audio.Settings()
+	if err := storage.Save(configFile, g.config); err != nil {
+		log.Printf("Failed to save settings: %v", err)
+	}
+}
+
+// applyWindow применяет размер окна и полноэкранный режим; размер окна
+// можно менять и мышью, кадр вписывается в любой
+func (g *Game) applyWindow() {
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowSize(ScreenWidth*g.config.WindowScale, ScreenHeight*g.config.WindowScale)
+	ebiten.SetFullscreen(g.config.Fullscreen)
+}
@@ -2,31 +2,42 @@ package game
 
 import (
 	"image/color"
+
+	"run-boy-run/sim"
+
 	"golang.org/x/image/font/basicfont"
 )
 
+// Геометрия мира задаётся в пакете sim
 const (
-	ScreenWidth    = 640
-	ScreenHeight   = 480
-	GridSize       = 32
-	GridWidth      = ScreenWidth / GridSize
-	GridHeight     = ScreenHeight / GridSize
-	PlayerSpeed    = 5
-	LaneSpacing    = GridSize * 1.5 // Расстояние между полосами
-	TextAreaHeight = 5              // Высота области для текста
+	ScreenWidth    = sim.ScreenWidth
+	ScreenHeight   = sim.ScreenHeight
+	GridSize       = sim.GridSize
+	GridWidth      = sim.GridWidth
+	GridHeight     = sim.GridHeight
+	PlayerSpeed    = sim.PlayerSpeed
+	LaneSpacing    = sim.LaneSpacing
+	TextAreaHeight = sim.TextAreaHeight
 )
 
 // Константы для уровней сложности
 const (
-	Easy = iota
-	Medium
-	Hard
+	Easy   = sim.Easy
+	Medium = sim.Medium
+	Hard   = sim.Hard
 )
 
 var (
 	Font = basicfont.Face7x13
 )
 
+// Уровень, который загружается для каждой сложности
+var difficultyLevels = map[int]string{
+	Easy:   "easy",
+	Medium: "medium",
+	Hard:   "hard",
+}
+
 func GetDifficultyName(level int) string {
 	switch level {
 	case Easy:
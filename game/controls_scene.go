@@ -0,0 +1,129 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// controlsScene - экран переназначения управления
+type controlsScene struct {
+	g       *Game
+	rows    []*Button // По строке на каждое действие
+	buttons *ButtonGroup
+	waiting Action // Действие, для которого ждём нажатие, или -1
+}
+
+func newControlsScene(g *Game) *controlsScene {
+	s := &controlsScene{g: g, waiting: -1}
+
+	var all []*Button
+	for a := Action(0); a < actionCount; a++ {
+		a := a
+		row := &Button{
+			X:      80,
+			Y:      float64(100 + int(a)*34),
+			Width:  ScreenWidth - 160,
+			Height: 30,
+			Font:   Font,
+			Action: func() { s.waiting = a },
+		}
+		s.rows = append(s.rows, row)
+		all = append(all, row)
+	}
+
+	all = append(all, &Button{
+		X:      ScreenWidth/2 - 210,
+		Y:      380,
+		Width:  200,
+		Height: 40,
+		Text:   "Reset Defaults",
+		Font:   Font,
+		Action: func() {
+			g.controls.Bindings = DefaultBindings()
+			s.save()
+		},
+	}, &Button{
+		X:      ScreenWidth/2 + 10,
+		Y:      380,
+		Width:  200,
+		Height: 40,
+		Text:   "Back",
+		Font:   Font,
+		Action: func() { g.switchScene(SceneSettings) },
+	})
+
+	s.buttons = NewButtonGroup(g.controls, all...)
+	return s
+}
+
+func (s *controlsScene) ID() SceneID { return SceneControls }
+
+func (s *controlsScene) Enter() {
+	s.waiting = -1
+	s.buttons.Focus(0)
+}
+
+func (s *controlsScene) Exit() {}
+
+func (s *controlsScene) Update() {
+	for a, row := range s.rows {
+		row.Text = fmt.Sprintf("%-12s %s", Action(a), s.g.controls.Bindings[Action(a)])
+	}
+
+	if s.waiting >= 0 {
+		// ESC всегда отменяет ожидание, поэтому его нельзя назначить
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			s.waiting = -1
+			return
+		}
+		if in, ok := s.g.controls.captureInput(); ok {
+			s.rebind(s.waiting, in)
+			s.waiting = -1
+		}
+		return
+	}
+
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneSettings)
+		return
+	}
+	s.buttons.Update()
+}
+
+// Новая клавиша заменяет клавиши действия, новая кнопка - кнопки геймпада
+func (s *controlsScene) rebind(a Action, in capturedInput) {
+	b := s.g.controls.Bindings[a]
+	if in.isPad {
+		b.Buttons = []PadButton{in.button}
+	} else {
+		b.Keys = []ebiten.Key{in.key}
+	}
+	s.g.controls.Bindings[a] = b
+	s.save()
+}
+
+func (s *controlsScene) save() {
+	s.g.saveConfig()
+}
+
+func (s *controlsScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	title := "CONTROLS"
+	titleBounds := text.BoundString(Font, title)
+	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 60, color.RGBA{255, 215, 0, 255})
+
+	s.buttons.Draw(screen)
+
+	hint := "Select an action and press Enter to rebind"
+	if s.waiting >= 0 {
+		hint = fmt.Sprintf("Press a key or gamepad button for %s (ESC to cancel)", s.waiting)
+	}
+	hintBounds := text.BoundString(Font, hint)
+	text.Draw(screen, hint, Font, ScreenWidth/2-hintBounds.Max.X/2, ScreenHeight-30, color.RGBA{200, 200, 200, 255})
+}
@@ -2,78 +2,173 @@ package game
 
 import (
 	"fmt"
-	"image"
 	"log"
-	"math/rand"
 	"os"
 	"time"
 
 	"image/color"
 
+	"run-boy-run/levels"
+	"run-boy-run/replay"
+	"run-boy-run/sim"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
+// Options - параметры запуска игры
+type Options struct {
+	Levels    *levels.Set
+	Seed      int64          // 0 - новый случайный seed для каждого забега
+	Record    string         // Куда сохранять реплей каждого законченного забега
+	Replay    *replay.Replay // Если задан, игра воспроизводит этот реплей
+	Assets    string         // Каталог со спрайтами, заменяющими встроенные
+	HotReload bool           // Перезагружать спрайты из Assets при изменении файлов
+}
+
 type Game struct {
-	player         *GameObject
+	world          *sim.World
+	clock          sim.FixedStep
+	recording      *replay.Replay
+	playback       *replay.Player
+	playbackSource *replay.Replay // Реплей, который сейчас воспроизводится
 	background     *ebiten.Image
-	objects        map[string]*ebiten.Image
-	cars           []*GameObject
-	currentTime    int
+	assets         *Assets
+	audio          *Audio
 	lastUpdateTime time.Time
-	gameState      string // "menu", "playing", "paused", "win", "lose"
-	elapsedTime    float64
+	scenes         *SceneMachine
+	nameEntry      *nameEntryScene
 	buttons        map[string]*Button
+	controls       *Controls
 	difficulty     int
-	levelTime      int // Время для текущего уровня
+	streak         int // Успешных переходов подряд, даёт множитель очков
+	popups         []scorePopup
+	effects        []effect
+	effectTick     int          // Шагов симуляции для эффектов: идут и после конца забега, на паузе стоят
+	campaign       *campaignRun // nil вне кампании
+	progress       campaignProgress
+	highScores     HighScores
+	playerName     string // Последнее введённое имя для таблицы рекордов
+	config         Config
+	canvas         *ebiten.Image // Кадр игры до масштабирования и коррекции палитры
+	view           viewport      // Где кадр лежит в окне
+	options        Options
 }
 
-func NewGame() *Game {
+func NewGame(opts Options) (*Game, error) {
+	for _, id := range difficultyLevels {
+		if opts.Levels.Level(id) == nil {
+			return nil, fmt.Errorf("level %q is missing", id)
+		}
+	}
 	g := &Game{
-		objects:     make(map[string]*ebiten.Image),
-		gameState:   "menu",
-		player:      &GameObject{},
-		buttons:     make(map[string]*Button),
-		difficulty:  Easy, // Начинаем с легкого уровня
+		options:    opts,
+		buttons:    make(map[string]*Button),
+		config:     LoadConfig(),
+		view:       identityView,
+		canvas:     ebiten.NewImage(ScreenWidth, ScreenHeight),
+		difficulty: Easy, // Начинаем с легкого уровня
+		progress:   loadCampaignProgress(),
+		highScores: loadHighScores(),
+	}
+	g.controls = NewControls(g.config.Bindings)
+	g.controls.view = &g.view
+	assets, err := LoadAssets(opts.Assets)
+	if err != nil {
+		return nil, err
+	}
+	g.assets = assets
+	if g.audio, err = NewAudio(g.config.Audio); err != nil {
+		return nil, err
 	}
-	g.LoadImages()
+	g.applyWindow()
+	// Фон меню, если в спрайтах нет background
+	g.background = g.createPlaceholderImage(ScreenWidth, ScreenHeight, color.RGBA{200, 200, 200, 255})
 	g.createButtons()
+	g.createScenes()
 	g.setDifficulty(Easy) // Устанавливаем начальную сложность
-	return g
+	if opts.Replay != nil {
+		if err := g.startPlayback(opts.Replay); err != nil {
+			return nil, err
+		}
+	} else {
+		g.switchScene(SceneMenu)
+	}
+	return g, nil
+}
+
+// Переходы между экранами; недопустимый переход - ошибка в коде, пишем в лог
+func (g *Game) switchScene(id SceneID) {
+	if err := g.scenes.Switch(id); err != nil {
+		log.Printf("Scene change failed: %v", err)
+	}
+}
+
+func (g *Game) pushScene(id SceneID) {
+	if err := g.scenes.Push(id); err != nil {
+		log.Printf("Scene change failed: %v", err)
+	}
+}
+
+func (g *Game) replaceScene(id SceneID) {
+	if err := g.scenes.Replace(id); err != nil {
+		log.Printf("Scene change failed: %v", err)
+	}
+}
+
+func (g *Game) popScene() {
+	if err := g.scenes.Pop(); err != nil {
+		log.Printf("Scene change failed: %v", err)
+	}
 }
 
 // Установка параметров сложности
 func (g *Game) setDifficulty(level int) {
 	g.difficulty = level
-	
-	switch level {
-	case Easy:
-		g.levelTime = 30 
-	case Medium:
-		g.levelTime = 25 
-	case Hard:
-		g.levelTime = 10 
-	}
-	
-	g.currentTime = g.levelTime
-	g.initializeGame()
+	g.campaign = nil
+	g.startLevel(g.options.Levels.Level(difficultyLevels[level]))
+}
+
+// Новый забег на уровне l
+func (g *Game) startLevel(l *sim.Level) {
+	cfg := sim.Config{
+		Difficulty: g.difficulty,
+		Level:      l,
+		Seed:       g.nextSeed(),
+		Mode:       g.config.Mode,
+		Streak:     g.streak,
+	}
+	g.world = sim.NewWorld(cfg)
+	g.clock.Reset()
+	g.recording = replay.New(cfg)
+	g.playback = nil
+	g.popups = nil
+	g.effects = nil
 }
 
-// Получение параметров для текущего уровня сложности
-func (g *Game) getLevelParams() (int, int, float64, float64, int, int) {
-	switch g.difficulty {
-	case Easy:
-		return 7, 2, 2, 2.5, 7, 11 
-	case Medium:
-		return 8, 3, 2.5, 3.5, 6, 9  
-	case Hard:
-		return 9, 4, 3, 4.5, 5, 7  
+// Повтор забега: реплея, уровня кампании или уровня сложности
+func (g *Game) restartRun() {
+	switch {
+	case g.playback != nil:
+		if err := g.startPlayback(g.playbackSource); err != nil {
+			log.Printf("Failed to restart replay: %v", err)
+		}
+		return
+	case g.campaign != nil:
+		g.startLevel(g.campaign.level(g))
 	default:
-		return 7, 2, 2, 2.5, 7, 11
+		g.setDifficulty(g.difficulty)
 	}
+	g.switchScene(ScenePlaying)
+}
+
+func (g *Game) nextSeed() int64 {
+	if g.options.Seed != 0 {
+		return g.options.Seed
+	}
+	return time.Now().UnixNano()
 }
 
 func (g *Game) createButtons() {
@@ -87,8 +182,7 @@ func (g *Game) createButtons() {
 		Font:    Font,
 		Action: func() {
 			g.setDifficulty(Easy)
-			g.gameState = "playing"
-			g.lastUpdateTime = time.Now()
+			g.switchScene(ScenePlaying)
 		},
 	}
 
@@ -101,8 +195,7 @@ func (g *Game) createButtons() {
 		Font:    Font,
 		Action: func() {
 			g.setDifficulty(Medium)
-			g.gameState = "playing"
-			g.lastUpdateTime = time.Now()
+			g.switchScene(ScenePlaying)
 		},
 	}
 
@@ -115,8 +208,7 @@ func (g *Game) createButtons() {
 		Font:    Font,
 		Action: func() {
 			g.setDifficulty(Hard)
-			g.gameState = "playing"
-			g.lastUpdateTime = time.Now()
+			g.switchScene(ScenePlaying)
 		},
 	}
 
@@ -124,7 +216,7 @@ func (g *Game) createButtons() {
 	g.buttons["exit_menu"] = &Button{
 		X:      ScreenWidth/2 - 100,
 		Y:      ScreenHeight/2 + 40,
-		Width:   200,
+		Width:   140,
 		Height:  40,
 		Text:    "Exit Game",
 		Font:    Font,
@@ -133,6 +225,45 @@ func (g *Game) createButtons() {
 		},
 	}
 
+	// Кнопка кампании в главном меню
+	g.buttons["campaign"] = &Button{
+		X:      ScreenWidth/2 - 210,
+		Y:      ScreenHeight/2 - 50,
+		Width:  200,
+		Height: 40,
+		Text:   "Campaign",
+		Font:   Font,
+		Action: func() {
+			g.switchScene(SceneCampaign)
+		},
+	}
+
+	// Кнопка таблицы рекордов в главном меню
+	g.buttons["highscores"] = &Button{
+		X:      ScreenWidth/2 + 10,
+		Y:      ScreenHeight/2 - 50,
+		Width:  200,
+		Height: 40,
+		Text:   "High Scores",
+		Font:   Font,
+		Action: func() {
+			g.switchScene(SceneHighScores)
+		},
+	}
+
+	// Кнопка настроек в главном меню
+	g.buttons["settings"] = &Button{
+		X:      ScreenWidth/2 - 150,
+		Y:      ScreenHeight - 80,
+		Width:  140,
+		Height: 40,
+		Text:   "Settings",
+		Font:   Font,
+		Action: func() {
+			g.switchScene(SceneSettings)
+		},
+	}
+
 	// Кнопка "Выйти в меню" в паузе
 	g.buttons["exit_pause"] = &Button{
 		X:      ScreenWidth/2 - 100,
@@ -142,7 +273,7 @@ func (g *Game) createButtons() {
 		Text:    "Back to Menu",
 		Font:    Font,
 		Action: func() {
-			g.gameState = "menu"
+			g.switchScene(SceneMenu)
 		},
 	}
 
@@ -155,9 +286,7 @@ func (g *Game) createButtons() {
 		Text:    "Play Again",
 		Font:    Font,
 		Action: func() {
-			g.setDifficulty(g.difficulty)
-			g.gameState = "playing"
-			g.lastUpdateTime = time.Now()
+			g.restartRun()
 		},
 	}
 
@@ -170,80 +299,11 @@ func (g *Game) createButtons() {
 		Text:    "Main Menu",
 		Font:    Font,
 		Action: func() {
-			g.gameState = "menu"
+			g.switchScene(SceneMenu)
 		},
 	}
 }
 
-func (g *Game) LoadImages() {
-	// Загрузка реальных изображений вместо цветных placeholder'ов
-	var err error
-	
-	// Загрузка изображения машины - исправлен путь
-	g.objects["car"], err = loadImageFromFile("../image/bus.png", 64, 32)
-	if err != nil {
-		log.Printf("Failed to load car image: %v, using placeholder", err)
-		g.objects["car"] = g.createPlaceholderImage(64, 32, color.RGBA{255, 0, 0, 255})
-	}
-	
-	// Загрузка изображения игрока
-	g.objects["player"], err = loadImageFromFile("../image/player.png", 32, 32)
-	if err != nil {
-		log.Printf("Failed to load player image: %v, using placeholder", err)
-		g.objects["player"] = g.createPlaceholderImage(32, 32, color.RGBA{0, 255, 0, 255})
-	}
-	
-	// Загрузка фонового изображения
-	g.objects["background"], err = loadImageFromFile("../image/back.png", ScreenWidth, ScreenHeight)
-	if err != nil {
-		log.Printf("Failed to load background image: %v, using placeholder", err)
-		g.objects["background"] = g.createPlaceholderImage(ScreenWidth, ScreenHeight, color.RGBA{200, 200, 200, 255})
-	}
-
-	g.background = g.objects["background"]
-	g.player.Image = g.objects["player"]
-}
-
-func loadImageFromFile(path string, targetWidth, targetHeight int) (*ebiten.Image, error) {
-	// Проверяем существование файла
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file not found: %s", path)
-	}
-
-	// Открываем файл
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Декодируем изображение
-	img, _, err := image.Decode(file)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Конвертируем в ebiten image
-	ebitenImg := ebiten.NewImageFromImage(img)
-	
-	// Масштабируем если нужно
-	if targetWidth > 0 && targetHeight > 0 {
-		origWidth, origHeight := ebitenImg.Size()
-		if origWidth != targetWidth || origHeight != targetHeight {
-			scaledImg := ebiten.NewImage(targetWidth, targetHeight)
-			op := &ebiten.DrawImageOptions{}
-			
-			scaleX := float64(targetWidth) / float64(origWidth)
-			scaleY := float64(targetHeight) / float64(origHeight)
-			op.GeoM.Scale(scaleX, scaleY)
-			
-			scaledImg.DrawImage(ebitenImg, op)
-			return scaledImg, nil
-		}
-	}
-	
-	return ebitenImg, nil
-}
 
 func (g *Game) createPlaceholderImage(width, height int, col color.RGBA) *ebiten.Image {
 	img := ebiten.NewImage(width, height)
@@ -251,205 +311,109 @@ func (g *Game) createPlaceholderImage(width, height int, col color.RGBA) *ebiten
 	return img
 }
 
-func (g *Game) initializeGame() {
-	// Получаем параметры для текущего уровня сложности
-	numLanes, numCarsPerLane, carSpeedMin, carSpeedMax, minCarGap, maxCarGap := g.getLevelParams()
-
-	// Настройка начального положения игрока
-	g.player = &GameObject{
-		X:      float64(GridWidth/2) * GridSize,
-		Y:      float64((GridHeight - 1) * GridSize),
-		Speed:  PlayerSpeed,
-		Image:  g.objects["player"],
-		Width:  GridSize,
-		Height: GridSize,
-	}
-
-	// Очистка существующих автомобилей
-	g.cars = []*GameObject{}
-
-	// Инициализация автомобилей на каждой полосе
-	for lane := 0; lane < numLanes; lane++ {
-		lastCarX := -float64(GridSize)
-		for i := 0; i < numCarsPerLane; i++ {
-			minGap := lastCarX + float64(minCarGap*GridSize)
-			maxGap := lastCarX + float64(maxCarGap*GridSize)
-			carX := minGap + rand.Float64()*(maxGap-minGap)
-
-			g.cars = append(g.cars, &GameObject{
-				X:       carX,
-				Y:       float64(lane)*LaneSpacing + TextAreaHeight,
-				Speed:   carSpeedMin + rand.Float64()*(carSpeedMax-carSpeedMin),
-				Image:   g.objects["car"],
-				Width:   GridSize * 2,
-				Height:  GridSize,
-				IsRight: rand.Intn(2) == 0,
-			})
-
-			lastCarX = carX
-		}
-	}
-}
-
 func (g *Game) Update() error {
-	// Обработка паузы
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) && (g.gameState == "playing" || g.gameState == "paused") {
-		if g.gameState == "playing" {
-			g.gameState = "paused"
-		} else {
-			g.gameState = "playing"
-			g.lastUpdateTime = time.Now()
-		}
+	if g.options.HotReload {
+		g.assets.CheckReload()
 	}
-
-	switch g.gameState {
-	case "menu":
-		g.updateMenu()
-	case "playing":
-		g.updateGame()
-	case "paused":
-		g.updatePaused()
-	case "win", "lose":
-		g.updateGameOver()
+	g.controls.Update()
+	// При вводе имени и переназначении клавиш M - обычная клавиша
+	if !g.scenes.Is(SceneNameEntry) && !g.scenes.Is(SceneControls) && g.controls.JustPressed(ActionMute) {
+		g.toggleMute()
 	}
-
+	g.scenes.Update()
+	g.audio.PlayMusic(sceneMusic(g.scenes.Current().ID()))
 	return nil
 }
 
-func (g *Game) updateMenu() {
-	// Обновление кнопок в меню
-	mx, my := ebiten.CursorPosition()
-	g.buttons["easy"].Hovered = g.buttons["easy"].Contains(float64(mx), float64(my))
-	g.buttons["medium"].Hovered = g.buttons["medium"].Contains(float64(mx), float64(my))
-	g.buttons["hard"].Hovered = g.buttons["hard"].Contains(float64(mx), float64(my))
-	g.buttons["exit_menu"].Hovered = g.buttons["exit_menu"].Contains(float64(mx), float64(my))
-
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		if g.buttons["easy"].Hovered {
-			g.buttons["easy"].Action()
-		}
-		if g.buttons["medium"].Hovered {
-			g.buttons["medium"].Action()
-		}
-		if g.buttons["hard"].Hovered {
-			g.buttons["hard"].Action()
-		}
-		if g.buttons["exit_menu"].Hovered {
-			g.buttons["exit_menu"].Action()
-		}
-	}
+func (g *Game) toggleMute() {
+	g.audio.ToggleMute()
+	g.saveConfig()
 }
 
-func (g *Game) updateGame() {
+// elapsedTicks возвращает, сколько шагов симуляции прошло с прошлого вызова
+func (g *Game) elapsedTicks() int {
 	now := time.Now()
 	elapsed := now.Sub(g.lastUpdateTime).Seconds()
 	g.lastUpdateTime = now
-
-	// Управление игроком
-	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		g.player.X -= float64(GridSize) * elapsed * PlayerSpeed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
-		g.player.X += float64(GridSize) * elapsed * PlayerSpeed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
-		g.player.Y -= float64(GridSize) * elapsed * PlayerSpeed
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
-		g.player.Y += float64(GridSize) * elapsed * PlayerSpeed
-	}
-
-	// Ограничение движения игрока
-	g.player.X = clamp(g.player.X, 0, ScreenWidth-float64(GridSize))
-	g.player.Y = clamp(g.player.Y, TextAreaHeight, ScreenHeight-float64(GridSize))
-
-	// Проверка победы - достиг верха экрана
-	if g.player.Y <= TextAreaHeight {
-		g.gameState = "win"
-		return
-	}
-
-	// Обновление автомобилей
-	for _, car := range g.cars {
-		car.Update(elapsed, float64(ScreenWidth))
-	}
-
-	// Проверка столкновений
-	g.checkCollisions()
-
-	// Обновление времени
-	g.elapsedTime += elapsed
-	if g.elapsedTime >= 1.0 {
-		g.currentTime -= 1
-		g.elapsedTime = 0
-		
-		// Проверка на проигрыш по времени
-		if g.currentTime <= 0 {
-			g.gameState = "lose" // Время вышло - проигрыш
-			return
-		}
-	}
+	return g.clock.Advance(elapsed)
 }
 
-func (g *Game) updatePaused() {
-	// Обновление кнопок в паузе
-	mx, my := ebiten.CursorPosition()
-	g.buttons["exit_pause"].Hovered = g.buttons["exit_pause"].Contains(float64(mx), float64(my))
-
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		if g.buttons["exit_pause"].Hovered {
-			g.buttons["exit_pause"].Action()
+func (g *Game) updateGame() {
+	g.updatePopups()
+	g.updateEffects()
+
+	input := g.controls.Movement()
+	for i := g.elapsedTicks(); i > 0; i-- {
+		g.effectTick++
+		for _, ev := range g.world.Step(sim.TickDuration, g.tickInput(input)) {
+			g.addScorePopup(ev)
+			switch ev.Kind {
+			case sim.EventWin:
+				g.audio.Play(sfxWin)
+				g.countCrossing(true)
+			case sim.EventNearMiss:
+				g.audio.Play(sfxHorn)
+			case sim.EventLifeLost:
+				g.audio.Play(sfxCrash)
+				g.addEffect("player_hit", ev.X, ev.Y, false)
+			case sim.EventHit:
+				g.audio.Play(sfxCrash)
+				g.addEffect("player_hit", ev.X, ev.Y, true)
+				g.countCrossing(false)
+			case sim.EventTimeUp:
+				g.audio.Play(sfxTimeUp)
+				g.countCrossing(false)
+			}
+		}
+		g.playStepSounds()
+		if g.world.Outcome != sim.Running {
+			g.endRun()
+			break
 		}
 	}
 }
 
-func (g *Game) updateGameOver() {
-	// Обновление кнопок
-	mx, my := ebiten.CursorPosition()
-	g.buttons["restart"].Hovered = g.buttons["restart"].Contains(float64(mx), float64(my))
-	g.buttons["menu"].Hovered = g.buttons["menu"].Contains(float64(mx), float64(my))
-
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		if g.buttons["restart"].Hovered {
-			g.buttons["restart"].Action()
-		}
-		if g.buttons["menu"].Hovered {
-			g.buttons["menu"].Action()
-		}
+// Забег окончен: итоги уровня кампании, ввод имени для рекорда или итоги забега
+func (g *Game) endRun() {
+	table := difficultyLevels[g.difficulty]
+	switch {
+	case g.campaign != nil && g.world.Outcome == sim.Won:
+		g.pushScene(SceneLevelComplete)
+	case g.campaign == nil && g.playback == nil && g.highScores.Qualifies(table, g.world.Score.Points):
+		g.askName(table, g.world.Score.Points, g.recording, SceneGameOver)
+		g.pushScene(SceneNameEntry)
+	default:
+		g.pushScene(SceneGameOver)
 	}
 }
 
-func (g *Game) checkCollisions() {
-	playerRect := g.player.GetRect()
-
-	for _, car := range g.cars {
-		carRect := car.GetRect()
-		if playerRect.Overlaps(carRect) {
-			g.gameState = "lose"
-			return
-		}
+// Серия успешных переходов; реплей её не меняет
+func (g *Game) countCrossing(won bool) {
+	if g.playback != nil {
+		return
+	}
+	if won {
+		g.streak++
+	} else {
+		g.streak = 0
 	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	// Отрисовка фона
-	if g.background != nil {
-		op := &ebiten.DrawImageOptions{}
-		screen.DrawImage(g.background, op)
-	}
-
-	switch g.gameState {
-	case "menu":
-		g.drawMenu(screen)
-	case "playing":
-		g.drawGame(screen)
-	case "paused":
-		g.drawGame(screen)
-		g.drawPauseMenu(screen)
-	case "win", "lose":
-		g.drawGame(screen)
-		g.drawGameOver(screen)
+	background := g.assets.Sprite("background")
+	if background == nil {
+		background = g.background
+	}
+	// Кадр рисуется в логическом разрешении и потом вписывается в окно
+	g.canvas.Clear()
+	g.canvas.DrawImage(background, &ebiten.DrawImageOptions{})
+
+	g.scenes.Draw(g.canvas)
+	if g.audio.Muted() {
+		ebitenutil.DebugPrintAt(g.canvas, "MUTED", ScreenWidth-50, ScreenHeight-40)
 	}
+	g.present(screen)
 }
 
 func (g *Game) drawMenu(screen *ebiten.Image) {
@@ -459,15 +423,15 @@ func (g *Game) drawMenu(screen *ebiten.Image) {
 	// Заголовок игры
 	title := "ROAD ADVENTURE"
 	titleBounds := text.BoundString(Font, title)
-	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 80, color.RGBA{255, 215, 0, 255}) // Золотой цвет
+	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 60, color.RGBA{255, 215, 0, 255}) // Золотой цвет
 
 	// Подзаголовок выбора сложности
 	diffText := "SELECT DIFFICULTY"
 	diffBounds := text.BoundString(Font, diffText)
-	text.Draw(screen, diffText, Font, ScreenWidth/2-diffBounds.Max.X/2, 140, color.White)
+	text.Draw(screen, diffText, Font, ScreenWidth/2-diffBounds.Max.X/2, 110, color.White)
 
 	// Расположение кнопок сложности в одну линию с равными промежутками
-	buttonY := 180
+	buttonY := 140
 	g.buttons["easy"].X = ScreenWidth/2 - 220
 	g.buttons["easy"].Y = float64(buttonY)
 	g.buttons["medium"].X = ScreenWidth/2 - 70
@@ -480,8 +444,14 @@ func (g *Game) drawMenu(screen *ebiten.Image) {
 	g.buttons["medium"].Draw(screen)
 	g.buttons["hard"].Draw(screen)
 
+	// Кампания и рекорды - под кнопками сложности
+	g.buttons["campaign"].Y = float64(buttonY + 50)
+	g.buttons["campaign"].Draw(screen)
+	g.buttons["highscores"].Y = float64(buttonY + 50)
+	g.buttons["highscores"].Draw(screen)
+
 	// Разделительная линия
-	separatorY := buttonY + 70
+	separatorY := buttonY + 110
 	vector.StrokeLine(screen, ScreenWidth/4, float32(separatorY), ScreenWidth*3/4, float32(separatorY), 2, color.RGBA{100, 100, 100, 255}, false)
 
 	// Управление
@@ -493,14 +463,18 @@ func (g *Game) drawMenu(screen *ebiten.Image) {
 		"W/A/S/D or Arrow Keys - Movement",
 		"Space - Pause/Resume",
 		"ESC - Back to Menu",
+		"M - Mute Sound",
+		"Tab/Arrows, Enter - Menu Navigation",
+		"Gamepad: D-Pad/Stick, A, B, Start",
 	}
 	for i, line := range controls {
 		lineBounds := text.BoundString(Font, line)
-		text.Draw(screen, line, Font, ScreenWidth/2-lineBounds.Max.X/2, separatorY+60+i*20, color.RGBA{200, 200, 200, 255})
+		text.Draw(screen, line, Font, ScreenWidth/2-lineBounds.Max.X/2, separatorY+55+i*18, color.RGBA{200, 200, 200, 255})
 	}
 
-	// Кнопка выхода - центрированная внизу
-	g.buttons["exit_menu"].X = ScreenWidth/2 - 100
+	// Кнопки настроек и выхода - внизу в одну линию
+	g.buttons["settings"].Draw(screen)
+	g.buttons["exit_menu"].X = ScreenWidth/2 + 10
 	g.buttons["exit_menu"].Y = float64(ScreenHeight - 80)
 	g.buttons["exit_menu"].Draw(screen)
 
@@ -511,19 +485,39 @@ func (g *Game) drawMenu(screen *ebiten.Image) {
 }
 
 func (g *Game) drawGame(screen *ebiten.Image) {
+	// Карта и плоты под игроком
+	g.drawTiles(screen)
+	for _, p := range g.world.Platforms {
+		drawObject(screen, p, g.vehicleFrame(p))
+	}
+
 	// Отрисовка автомобилей
-	for _, car := range g.cars {
-		car.Draw(screen)
+	for _, car := range g.world.Cars {
+		drawObject(screen, car, g.vehicleFrame(car))
 	}
 
-	// Отрисовка игрока
-	g.player.Draw(screen)
+	// Отрисовка игрока; после потери жизни он мигает, пока неуязвим,
+	// а после последней его заменяет анимация удара
+	inv := g.world.Invulnerable()
+	if g.world.Lives > 0 && (inv == 0 || inv/invulnerableBlink%2 == 0) {
+		drawObject(screen, g.world.Player, g.playerFrame())
+	}
+	g.drawEffects(screen)
+	g.drawPopups(screen)
 
 	// Отрисовка времени и уровня сложности
-	levelText := GetDifficultyName(g.difficulty)
+	levelText := g.world.Level.Name
+	if g.campaign != nil {
+		levelText = fmt.Sprintf("Campaign %d/%d - %s", g.campaign.index+1, len(g.options.Levels.Campaign), levelText)
+	}
 	
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Time: %d", g.currentTime), 10, 10)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Time: %d", g.world.CurrentTime), 10, 10)
 	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Level: %s", levelText), 10, 30)
+	g.drawScore(screen)
+	g.drawLives(screen)
+	if g.playback != nil {
+		ebitenutil.DebugPrintAt(screen, "REPLAY", ScreenWidth-60, 10)
+	}
 }
 
 func (g *Game) drawPauseMenu(screen *ebiten.Image) {
@@ -545,42 +539,39 @@ func (g *Game) drawGameOver(screen *ebiten.Image) {
 
 	// Текст результата
 	resultText := ""
-	if g.gameState == "win" {
+	if g.world.Outcome == sim.Won {
 		resultText = "VICTORY!"
 	} else {
 		resultText = "GAME OVER!"
 	}
 
 	reasonText := ""
-	if g.gameState == "lose" && g.currentTime <= 0 {
+	if g.world.Outcome == sim.Lost && g.world.CurrentTime <= 0 {
 		reasonText = "Time's up!"
-	} else if g.gameState == "lose" {
-		reasonText = "You got hit!"
+	} else if g.world.Outcome == sim.Lost {
+		reasonText = "Out of lives!"
 	} else {
 		reasonText = "You made it!"
 	}
 
 	resultBounds := text.BoundString(Font, resultText)
-	text.Draw(screen, resultText, Font, ScreenWidth/2-resultBounds.Max.X/2, ScreenHeight/2-80, color.White)
+	text.Draw(screen, resultText, Font, ScreenWidth/2-resultBounds.Max.X/2, ScreenHeight/2-95, color.White)
 	
 	reasonBounds := text.BoundString(Font, reasonText)
-	text.Draw(screen, reasonText, Font, ScreenWidth/2-reasonBounds.Max.X/2, ScreenHeight/2-50, color.White)
+	text.Draw(screen, reasonText, Font, ScreenWidth/2-reasonBounds.Max.X/2, ScreenHeight/2-70, color.White)
+
+	scoreText := fmt.Sprintf("Score: %d", g.world.Score.Points)
+	scoreBounds := text.BoundString(Font, scoreText)
+	text.Draw(screen, scoreText, Font, ScreenWidth/2-scoreBounds.Max.X/2, ScreenHeight/2-45, color.RGBA{255, 215, 0, 255})
+
+	// Seed забега, чтобы его можно было повторить
+	seedText := fmt.Sprintf("Seed: %d", g.world.Seed)
+	seedBounds := text.BoundString(Font, seedText)
+	text.Draw(screen, seedText, Font, ScreenWidth/2-seedBounds.Max.X/2, ScreenHeight/2-20, color.RGBA{200, 200, 200, 255})
 
 	// Кнопки
 	g.buttons["restart"].Draw(screen)
 	g.buttons["menu"].Draw(screen)
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return ScreenWidth, ScreenHeight
-}
 
-func clamp(value, min, max float64) float64 {
-	if value < min {
-		return min
-	}
-	if value > max {
-		return max
-	}
-	return value
-}
\ No newline at end of file
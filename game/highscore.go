@@ -0,0 +1,325 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"run-boy-run/replay"
+	"run-boy-run/storage"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	highScoresFile = "highscores.json"
+	replaysDir     = "replays"
+	maxHighScores  = 10
+	maxNameLength  = 12
+)
+
+// Таблица рекордов кампании; остальные таблицы - по ID уровня сложности
+const campaignTable = "campaign"
+
+var highScoreTables = []string{"easy", "medium", "hard", campaignTable}
+
+type HighScore struct {
+	Name   string    `json:"name"`
+	Score  int       `json:"score"`
+	Date   time.Time `json:"date"`
+	Replay string    `json:"replay,omitempty"` // Файл реплея в каталоге настроек
+}
+
+// HighScores - таблицы рекордов, лучшие сверху
+type HighScores map[string][]HighScore
+
+// loadHighScores читает таблицы; испорченный файл откладывается в сторону
+func loadHighScores() HighScores {
+	h := HighScores{}
+	if err := storage.LoadOrQuarantine(highScoresFile, "high scores", &h); err != nil {
+		return HighScores{}
+	}
+	return h
+}
+
+func (h HighScores) save() error {
+	return storage.Save(highScoresFile, h)
+}
+
+// Qualifies сообщает, попадёт ли счёт в таблицу
+func (h HighScores) Qualifies(table string, score int) bool {
+	if score <= 0 {
+		return false
+	}
+	entries := h[table]
+	return len(entries) < maxHighScores || score > entries[len(entries)-1].Score
+}
+
+// Add вставляет рекорд и обрезает таблицу. Возвращает место (с нуля) или -1
+func (h HighScores) Add(table string, e HighScore) int {
+	entries := append(h[table], e)
+	// При равном счёте выше тот, кто поставил его раньше
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+
+	rank := -1
+	for i := range entries {
+		if entries[i] == e {
+			rank = i
+			break
+		}
+	}
+	if len(entries) > maxHighScores {
+		for _, dropped := range entries[maxHighScores:] {
+			removeReplay(dropped.Replay)
+		}
+		entries = entries[:maxHighScores]
+	}
+	h[table] = entries
+	if rank >= maxHighScores {
+		rank = -1
+	}
+	return rank
+}
+
+// saveRunReplay сохраняет реплей рекордного забега рядом с таблицей и возвращает его имя
+func saveRunReplay(rep *replay.Replay) (string, error) {
+	var buf bytes.Buffer
+	if err := rep.Encode(&buf); err != nil {
+		return "", err
+	}
+	name := filepath.Join(replaysDir, time.Now().Format("20060102-150405.000")+".rbr")
+	return name, storage.SaveFile(name, buf.Bytes())
+}
+
+func loadRunReplay(name string) (*replay.Replay, error) {
+	path, err := storage.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return replay.Load(path)
+}
+
+func removeReplay(name string) {
+	if name == "" {
+		return
+	}
+	if path, err := storage.Path(name); err == nil {
+		os.Remove(path)
+	}
+}
+
+// Подготовка ввода имени: после сохранения откроется экран then
+func (g *Game) askName(table string, score int, rec *replay.Replay, then SceneID) {
+	g.nameEntry.table = table
+	g.nameEntry.score = score
+	g.nameEntry.recording = rec
+	g.nameEntry.then = then
+}
+
+// nameEntryScene - ввод имени для рекорда
+type nameEntryScene struct {
+	g         *Game
+	table     string
+	score     int
+	recording *replay.Replay // Запись забега; у кампании её нет
+	then      SceneID
+	name      []rune
+	chars     []rune
+}
+
+func (s *nameEntryScene) ID() SceneID { return SceneNameEntry }
+
+func (s *nameEntryScene) Enter() {
+	s.name = []rune(s.g.playerName)
+}
+
+func (s *nameEntryScene) Exit() {}
+
+func (s *nameEntryScene) Update() {
+	s.g.tickEffects()
+	// Back пропускает ввод, рекорд не сохраняется
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.replaceScene(s.then)
+		return
+	}
+	// Пробел пишется в имя, поэтому подтверждаем только Enter или кнопкой геймпада
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) ||
+		s.g.controls.padJustPressed(ActionConfirm) {
+		s.save()
+		s.g.replaceScene(s.then)
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(s.name) > 0 {
+		s.name = s.name[:len(s.name)-1]
+	}
+	s.chars = ebiten.AppendInputChars(s.chars[:0])
+	for _, r := range s.chars {
+		if len(s.name) < maxNameLength && unicode.IsPrint(r) {
+			s.name = append(s.name, r)
+		}
+	}
+}
+
+func (s *nameEntryScene) save() {
+	name := strings.TrimSpace(string(s.name))
+	if name == "" {
+		name = "Player"
+	}
+	s.g.playerName = name
+
+	entry := HighScore{Name: name, Score: s.score, Date: time.Now()}
+	if s.recording != nil {
+		file, err := saveRunReplay(s.recording)
+		if err != nil {
+			log.Printf("Failed to save high score replay: %v", err)
+		} else {
+			entry.Replay = file
+		}
+	}
+	s.g.highScores.Add(s.table, entry)
+	if err := s.g.highScores.save(); err != nil {
+		log.Printf("Failed to save high scores: %v", err)
+	}
+}
+
+func (s *nameEntryScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	lines := []string{
+		"NEW HIGH SCORE!",
+		fmt.Sprintf("%s: %d", strings.ToUpper(s.table), s.score),
+		"Enter your name:",
+	}
+	for i, line := range lines {
+		clr := color.Color(color.White)
+		if i == 0 {
+			clr = color.RGBA{255, 215, 0, 255}
+		}
+		bounds := text.BoundString(Font, line)
+		text.Draw(screen, line, Font, ScreenWidth/2-bounds.Max.X/2, ScreenHeight/2-90+i*25, clr)
+	}
+
+	// Поле ввода с мигающим курсором
+	vector.DrawFilledRect(screen, ScreenWidth/2-120, ScreenHeight/2, 240, 36, color.RGBA{40, 40, 40, 255}, false)
+	vector.StrokeRect(screen, ScreenWidth/2-120, ScreenHeight/2, 240, 36, 1, color.White, false)
+	field := string(s.name)
+	if time.Now().UnixMilli()/500%2 == 0 {
+		field += "_"
+	}
+	text.Draw(screen, field, Font, ScreenWidth/2-110, ScreenHeight/2+24, color.White)
+
+	hint := "Enter to save, ESC to skip"
+	hintBounds := text.BoundString(Font, hint)
+	text.Draw(screen, hint, Font, ScreenWidth/2-hintBounds.Max.X/2, ScreenHeight/2+70, color.RGBA{200, 200, 200, 255})
+}
+
+// highScoresScene - таблицы рекордов; строка с реплеем воспроизводит забег
+type highScoresScene struct {
+	g       *Game
+	table   int // Индекс в highScoreTables
+	buttons *ButtonGroup
+}
+
+func newHighScoresScene(g *Game) *highScoresScene {
+	return &highScoresScene{g: g}
+}
+
+func (s *highScoresScene) ID() SceneID { return SceneHighScores }
+
+func (s *highScoresScene) Enter() {
+	s.rebuild()
+}
+
+// Кнопки пересобираются при смене таблицы
+func (s *highScoresScene) rebuild() {
+	g := s.g
+	var buttons []*Button
+	for i, e := range g.highScores[highScoreTables[s.table]] {
+		e := e
+		b := &Button{
+			X:      80,
+			Y:      float64(70 + i*28),
+			Width:  ScreenWidth - 160,
+			Height: 26,
+			Text:   fmt.Sprintf("%2d. %-12s %6d  %s", i+1, e.Name, e.Score, e.Date.Format("2006-01-02")),
+			Font:   Font,
+			Action: func() { s.watch(e) },
+		}
+		if e.Replay == "" {
+			b.Disabled = true
+		}
+		buttons = append(buttons, b)
+	}
+	buttons = append(buttons, &Button{
+		X:      ScreenWidth/2 - 100,
+		Y:      ScreenHeight - 80,
+		Width:  200,
+		Height: 40,
+		Text:   "Back",
+		Font:   Font,
+		Action: func() { g.switchScene(SceneMenu) },
+	})
+	s.buttons = NewButtonGroup(g.controls, buttons...)
+}
+
+// Просмотр реплея рекорда
+func (s *highScoresScene) watch(e HighScore) {
+	rep, err := loadRunReplay(e.Replay)
+	if err == nil {
+		err = s.g.startPlayback(rep)
+	}
+	if err != nil {
+		log.Printf("Failed to play high score replay: %v", err)
+	}
+}
+
+func (s *highScoresScene) Exit() {}
+
+func (s *highScoresScene) Update() {
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	// Влево и вправо листают таблицы, поэтому проверяются раньше кнопок
+	switch {
+	case s.g.controls.JustPressed(ActionLeft):
+		s.table = (s.table + len(highScoreTables) - 1) % len(highScoreTables)
+		s.rebuild()
+		return
+	case s.g.controls.JustPressed(ActionRight):
+		s.table = (s.table + 1) % len(highScoreTables)
+		s.rebuild()
+		return
+	}
+	s.buttons.Update()
+}
+
+func (s *highScoresScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	title := "HIGH SCORES: " + strings.ToUpper(highScoreTables[s.table])
+	titleBounds := text.BoundString(Font, title)
+	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 50, color.RGBA{255, 215, 0, 255})
+
+	if len(s.g.highScores[highScoreTables[s.table]]) == 0 {
+		empty := "No scores yet"
+		bounds := text.BoundString(Font, empty)
+		text.Draw(screen, empty, Font, ScreenWidth/2-bounds.Max.X/2, ScreenHeight/2, color.RGBA{200, 200, 200, 255})
+	}
+	s.buttons.Draw(screen)
+
+	hint := "Left/Right: switch table   Enter: watch replay"
+	hintBounds := text.BoundString(Font, hint)
+	text.Draw(screen, hint, Font, ScreenWidth/2-hintBounds.Max.X/2, ScreenHeight-20, color.RGBA{200, 200, 200, 255})
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"run-boy-run/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	popupLifetime     = 45 // Сколько кадров видна надпись о начисленных очках
+	invulnerableBlink = 6  // Шагов на одну фазу мигания неуязвимого игрока
+)
+
+// scorePopup - всплывающая над игроком надпись "+25 NEAR MISS"
+type scorePopup struct {
+	text string
+	x, y float64
+	age  int
+}
+
+var scoreLabels = map[sim.EventKind]string{
+	sim.EventLaneCrossed: "",
+	sim.EventNearMiss:    " NEAR MISS",
+	sim.EventTimeBonus:   " TIME BONUS",
+}
+
+// addScorePopup показывает очки из события, если оно про очки
+func (g *Game) addScorePopup(ev sim.Event) {
+	label, ok := scoreLabels[ev.Kind]
+	if !ok {
+		return
+	}
+	g.popups = append(g.popups, scorePopup{
+		text: fmt.Sprintf("+%d%s", ev.Points, label),
+		x:    g.world.Player.X,
+		y:    g.world.Player.Y - 14,
+	})
+}
+
+func (g *Game) updatePopups() {
+	alive := g.popups[:0]
+	for _, p := range g.popups {
+		p.age++
+		if p.age < popupLifetime {
+			alive = append(alive, p)
+		}
+	}
+	g.popups = alive
+}
+
+func (g *Game) drawPopups(screen *ebiten.Image) {
+	for _, p := range g.popups {
+		ebitenutil.DebugPrintAt(screen, p.text, int(p.x), int(p.y)-p.age/2)
+	}
+}
+
+// Счёт и множитель в углу экрана
+func (g *Game) drawScore(screen *ebiten.Image) {
+	score := fmt.Sprintf("Score: %d", g.world.Score.Points)
+	if m := g.world.Score.Multiplier; m > 1 {
+		score += fmt.Sprintf("  x%d", m)
+	}
+	ebitenutil.DebugPrintAt(screen, score, 10, 50)
+}
+
+// Оставшиеся жизни - уменьшенные фигурки игрока под счётом
+func (g *Game) drawLives(screen *ebiten.Image) {
+	ebitenutil.DebugPrintAt(screen, "Lives:", 10, 70)
+	for i := 0; i < g.world.Lives; i++ {
+		x, y := float64(52+i*20), 70.0
+		if img := g.assets.Sprite("player"); img != nil {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Scale(0.5, 0.5)
+			op.GeoM.Translate(x, y)
+			screen.DrawImage(img, op)
+		} else {
+			vector.DrawFilledRect(screen, float32(x), float32(y), GridSize/2, GridSize/2, color.RGBA{255, 0, 0, 255}, false)
+		}
+	}
+}
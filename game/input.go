@@ -0,0 +1,346 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"run-boy-run/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Action - логическое действие игрока, не зависящее от устройства ввода
+type Action int
+
+const (
+	ActionUp Action = iota
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionPause
+	ActionConfirm
+	ActionBack
+	ActionMute
+	actionCount
+)
+
+var actionNames = [actionCount]string{"up", "down", "left", "right", "pause", "confirm", "back", "mute"}
+
+var actionTitles = [actionCount]string{"Move Up", "Move Down", "Move Left", "Move Right", "Pause", "Confirm", "Back", "Mute Sound"}
+
+func (a Action) String() string {
+	if a < 0 || a >= actionCount {
+		return fmt.Sprintf("action(%d)", int(a))
+	}
+	return actionTitles[a]
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= actionCount {
+		return nil, fmt.Errorf("unknown action %d", int(a))
+	}
+	return []byte(actionNames[a]), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for i, name := range actionNames {
+		if name == string(text) {
+			*a = Action(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// PadButton - кнопка геймпада в стандартной раскладке
+type PadButton ebiten.StandardGamepadButton
+
+var padButtonNames = map[PadButton]string{
+	PadButton(ebiten.StandardGamepadButtonRightBottom):      "A",
+	PadButton(ebiten.StandardGamepadButtonRightRight):       "B",
+	PadButton(ebiten.StandardGamepadButtonRightLeft):        "X",
+	PadButton(ebiten.StandardGamepadButtonRightTop):         "Y",
+	PadButton(ebiten.StandardGamepadButtonFrontTopLeft):     "LB",
+	PadButton(ebiten.StandardGamepadButtonFrontTopRight):    "RB",
+	PadButton(ebiten.StandardGamepadButtonFrontBottomLeft):  "LT",
+	PadButton(ebiten.StandardGamepadButtonFrontBottomRight): "RT",
+	PadButton(ebiten.StandardGamepadButtonCenterLeft):       "Select",
+	PadButton(ebiten.StandardGamepadButtonCenterRight):      "Start",
+	PadButton(ebiten.StandardGamepadButtonLeftStick):        "LS",
+	PadButton(ebiten.StandardGamepadButtonRightStick):       "RS",
+	PadButton(ebiten.StandardGamepadButtonLeftTop):          "DPadUp",
+	PadButton(ebiten.StandardGamepadButtonLeftBottom):       "DPadDown",
+	PadButton(ebiten.StandardGamepadButtonLeftLeft):         "DPadLeft",
+	PadButton(ebiten.StandardGamepadButtonLeftRight):        "DPadRight",
+	PadButton(ebiten.StandardGamepadButtonCenterCenter):     "Home",
+}
+
+func (b PadButton) String() string {
+	if name, ok := padButtonNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Button%d", int(b))
+}
+
+func (b PadButton) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *PadButton) UnmarshalText(text []byte) error {
+	for btn, name := range padButtonNames {
+		if name == string(text) {
+			*b = btn
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown gamepad button %q", text)
+}
+
+// PadAxis - направление стика геймпада, например "LeftX-"
+type PadAxis struct {
+	Axis     ebiten.StandardGamepadAxis
+	Negative bool
+}
+
+var padAxisNames = map[ebiten.StandardGamepadAxis]string{
+	ebiten.StandardGamepadAxisLeftStickHorizontal:  "LeftX",
+	ebiten.StandardGamepadAxisLeftStickVertical:    "LeftY",
+	ebiten.StandardGamepadAxisRightStickHorizontal: "RightX",
+	ebiten.StandardGamepadAxisRightStickVertical:   "RightY",
+}
+
+func (a PadAxis) String() string {
+	sign := "+"
+	if a.Negative {
+		sign = "-"
+	}
+	return padAxisNames[a.Axis] + sign
+}
+
+func (a PadAxis) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *PadAxis) UnmarshalText(text []byte) error {
+	s := string(text)
+	if len(s) < 2 {
+		return fmt.Errorf("unknown gamepad axis %q", s)
+	}
+	name, sign := s[:len(s)-1], s[len(s)-1]
+	for axis, n := range padAxisNames {
+		if n == name && (sign == '+' || sign == '-') {
+			*a = PadAxis{Axis: axis, Negative: sign == '-'}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown gamepad axis %q", s)
+}
+
+// Binding - клавиши, кнопки и стики, которые вызывают одно действие
+type Binding struct {
+	Keys    []ebiten.Key `json:"keys"`
+	Buttons []PadButton  `json:"buttons"`
+	Axes    []PadAxis    `json:"axes,omitempty"`
+}
+
+func (b Binding) String() string {
+	var parts []string
+	for _, k := range b.Keys {
+		parts = append(parts, k.String())
+	}
+	for _, btn := range b.Buttons {
+		parts = append(parts, "Pad "+btn.String())
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+type Bindings map[Action]Binding
+
+// UnmarshalJSON пропускает действия и привязки, которые не удалось разобрать
+// (например, записанные более новой версией игры): для них остаются прежние
+// привязки, а остальные читаются как обычно
+func (b *Bindings) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if *b == nil {
+		*b = Bindings{}
+	}
+	for name, value := range raw {
+		var a Action
+		var binding Binding
+		if err := a.UnmarshalText([]byte(name)); err != nil {
+			log.Printf("Ignoring key binding: %v", err)
+			continue
+		}
+		if err := json.Unmarshal(value, &binding); err != nil {
+			log.Printf("Ignoring key binding for %s: %v", name, err)
+			continue
+		}
+		(*b)[a] = binding
+	}
+	return nil
+}
+
+func DefaultBindings() Bindings {
+	pad := func(b ebiten.StandardGamepadButton) PadButton { return PadButton(b) }
+	return Bindings{
+		ActionUp: {
+			Keys:    []ebiten.Key{ebiten.KeyW, ebiten.KeyUp},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonLeftTop)},
+			Axes:    []PadAxis{{ebiten.StandardGamepadAxisLeftStickVertical, true}},
+		},
+		ActionDown: {
+			Keys:    []ebiten.Key{ebiten.KeyS, ebiten.KeyDown},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonLeftBottom)},
+			Axes:    []PadAxis{{ebiten.StandardGamepadAxisLeftStickVertical, false}},
+		},
+		ActionLeft: {
+			Keys:    []ebiten.Key{ebiten.KeyA, ebiten.KeyLeft},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonLeftLeft)},
+			Axes:    []PadAxis{{ebiten.StandardGamepadAxisLeftStickHorizontal, true}},
+		},
+		ActionRight: {
+			Keys:    []ebiten.Key{ebiten.KeyD, ebiten.KeyRight},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonLeftRight)},
+			Axes:    []PadAxis{{ebiten.StandardGamepadAxisLeftStickHorizontal, false}},
+		},
+		ActionPause: {
+			Keys:    []ebiten.Key{ebiten.KeySpace},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonCenterRight)},
+		},
+		ActionConfirm: {
+			Keys:    []ebiten.Key{ebiten.KeyEnter, ebiten.KeyNumpadEnter, ebiten.KeySpace},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonRightBottom)},
+		},
+		ActionBack: {
+			Keys:    []ebiten.Key{ebiten.KeyEscape},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonRightRight)},
+		},
+		ActionMute: {
+			Keys:    []ebiten.Key{ebiten.KeyM},
+			Buttons: []PadButton{pad(ebiten.StandardGamepadButtonCenterLeft)},
+		},
+	}
+}
+
+// Порог отклонения стика, после которого он считается нажатым
+const axisDeadZone = 0.5
+
+// Controls отвечает на вопрос "нажато ли действие" сразу для клавиатуры и всех геймпадов
+type Controls struct {
+	Bindings Bindings
+	view     *viewport // Для перевода курсора в координаты кадра; nil - один к одному
+	gamepads []ebiten.GamepadID
+	axisCur  [actionCount]bool
+	axisPrev [actionCount]bool
+}
+
+func NewControls(b Bindings) *Controls {
+	return &Controls{Bindings: b}
+}
+
+// Update опрашивает геймпады; вызывается один раз в начале кадра
+func (c *Controls) Update() {
+	c.gamepads = ebiten.AppendGamepadIDs(c.gamepads[:0])
+	c.axisPrev = c.axisCur
+	for a := Action(0); a < actionCount; a++ {
+		c.axisCur[a] = false
+		for _, axis := range c.Bindings[a].Axes {
+			for _, id := range c.gamepads {
+				v := ebiten.StandardGamepadAxisValue(id, axis.Axis)
+				if axis.Negative {
+					v = -v
+				}
+				if v > axisDeadZone {
+					c.axisCur[a] = true
+				}
+			}
+		}
+	}
+}
+
+func (c *Controls) Pressed(a Action) bool {
+	b := c.Bindings[a]
+	for _, k := range b.Keys {
+		if ebiten.IsKeyPressed(k) {
+			return true
+		}
+	}
+	for _, id := range c.gamepads {
+		for _, btn := range b.Buttons {
+			if ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButton(btn)) {
+				return true
+			}
+		}
+	}
+	return c.axisCur[a]
+}
+
+func (c *Controls) JustPressed(a Action) bool {
+	b := c.Bindings[a]
+	for _, k := range b.Keys {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return c.padJustPressed(a) || c.axisCur[a] && !c.axisPrev[a]
+}
+
+// padJustPressed проверяет только кнопки геймпада: при вводе текста клавиатура занята
+func (c *Controls) padJustPressed(a Action) bool {
+	for _, id := range c.gamepads {
+		for _, btn := range c.Bindings[a].Buttons {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButton(btn)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Cursor возвращает положение мыши в координатах кадра игры при любом размере окна
+func (c *Controls) Cursor() (int, int) {
+	x, y := ebiten.CursorPosition()
+	if c.view == nil {
+		return x, y
+	}
+	return c.view.toLogical(x, y)
+}
+
+// Movement - состояние управления персонажем для симуляции
+func (c *Controls) Movement() sim.Input {
+	return sim.Input{
+		Left:  c.Pressed(ActionLeft),
+		Right: c.Pressed(ActionRight),
+		Up:    c.Pressed(ActionUp),
+		Down:  c.Pressed(ActionDown),
+	}
+}
+
+// capturedInput - нажатие, пойманное экраном переназначения управления
+type capturedInput struct {
+	key    ebiten.Key
+	button PadButton
+	isPad  bool
+}
+
+// captureInput возвращает первую только что нажатую клавишу или кнопку геймпада
+func (c *Controls) captureInput() (capturedInput, bool) {
+	if keys := inpututil.AppendJustPressedKeys(nil); len(keys) > 0 {
+		return capturedInput{key: keys[0]}, true
+	}
+	for _, id := range c.gamepads {
+		if btns := inpututil.AppendJustPressedStandardGamepadButtons(id, nil); len(btns) > 0 {
+			return capturedInput{button: PadButton(btns[0]), isPad: true}, true
+		}
+	}
+	return capturedInput{}, false
+}
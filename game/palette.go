@@ -0,0 +1,81 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+// Palette - цветовая схема для игроков с нарушениями цветового зрения.
+// Кадр целиком проходит через матрицу, которая переносит неразличимую
+// разницу цветов в различимые каналы, поэтому спрайты менять не нужно
+type Palette int
+
+const (
+	PaletteDefault Palette = iota
+	PaletteProtanopia
+	PaletteDeuteranopia
+	PaletteTritanopia
+	paletteCount
+)
+
+var paletteNames = [paletteCount]string{"default", "protanopia", "deuteranopia", "tritanopia"}
+
+var paletteTitles = [paletteCount]string{"Default", "Protanopia", "Deuteranopia", "Tritanopia"}
+
+func (p Palette) String() string {
+	if p < 0 || p >= paletteCount {
+		return fmt.Sprintf("palette(%d)", int(p))
+	}
+	return paletteTitles[p]
+}
+
+func (p Palette) MarshalText() ([]byte, error) {
+	if p < 0 || p >= paletteCount {
+		return nil, fmt.Errorf("unknown palette %d", int(p))
+	}
+	return []byte(paletteNames[p]), nil
+}
+
+func (p *Palette) UnmarshalText(text []byte) error {
+	for i, name := range paletteNames {
+		if name == string(text) {
+			*p = Palette(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette %q", text)
+}
+
+// Как видит цвета RGB человек с каждым типом дальтонизма
+var paletteSimulation = [paletteCount][3][3]float64{
+	PaletteProtanopia:   {{0.567, 0.433, 0}, {0.558, 0.442, 0}, {0, 0.242, 0.758}},
+	PaletteDeuteranopia: {{0.625, 0.375, 0}, {0.7, 0.3, 0}, {0, 0.3, 0.7}},
+	PaletteTritanopia:   {{0.95, 0.05, 0}, {0, 0.433, 0.567}, {0, 0.475, 0.525}},
+}
+
+// Куда переносится потерянная разница: из красного в зелёный и синий
+var paletteShift = [3][3]float64{{0, 0, 0}, {0.7, 1, 0}, {0.7, 0, 1}}
+
+// matrix возвращает матрицу коррекции I + shift*(I - simulation)
+func (p Palette) matrix() colorm.ColorM {
+	var m colorm.ColorM
+	sim := paletteSimulation[p]
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			v := 0.0
+			for k := 0; k < 3; k++ {
+				lost := -sim[k][j]
+				if k == j {
+					lost++
+				}
+				v += paletteShift[i][k] * lost
+			}
+			if i == j {
+				v++
+			}
+			m.SetElement(i, j, v)
+		}
+	}
+	return m
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"run-boy-run/levels"
+	"run-boy-run/replay"
+	"run-boy-run/sim"
+)
+
+// Запуск воспроизведения реплея вместо управления с клавиатуры
+func (g *Game) startPlayback(rep *replay.Replay) error {
+	level, err := replayLevel(g.options.Levels, rep)
+	if err != nil {
+		return err
+	}
+
+	cfg := rep.Config()
+	cfg.Level = level
+	g.difficulty = rep.Difficulty
+	g.campaign = nil
+	g.world = sim.NewWorld(cfg)
+	g.clock.Reset()
+	g.recording = nil
+	g.popups = nil
+	g.effects = nil
+	g.playbackSource = rep
+	g.playback = replay.NewPlayer(rep)
+	g.switchScene(ScenePlaying)
+	return nil
+}
+
+// Уровень реплея; у старых файлов без уровня - уровень его сложности
+func replayLevel(set *levels.Set, rep *replay.Replay) (*sim.Level, error) {
+	id := rep.Level
+	if id == "" {
+		id = difficultyLevels[rep.Difficulty]
+	}
+	l := set.Level(id)
+	if l == nil {
+		return nil, fmt.Errorf("replay needs level %q, which is not loaded", id)
+	}
+	if err := rep.CheckLevel(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Ввод на очередной шаг: из реплея или с клавиатуры с записью
+func (g *Game) tickInput(polled sim.Input) sim.Input {
+	if g.playback != nil {
+		in, _ := g.playback.Next()
+		return in
+	}
+	if g.recording != nil {
+		g.recording.Record(polled)
+	}
+	return polled
+}
+
+// Сохранение записи законченного забега
+func (g *Game) finishRun() {
+	if g.recording == nil || g.options.Record == "" {
+		return
+	}
+	if err := replay.Save(g.options.Record, g.recording); err != nil {
+		log.Printf("Failed to save replay: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type SceneID int
+
+const (
+	SceneMenu SceneID = iota
+	ScenePlaying
+	ScenePaused
+	SceneGameOver
+	SceneControls
+	SceneCampaign
+	SceneLevelComplete
+	SceneHighScores
+	SceneNameEntry
+	SceneSettings
+)
+
+func (id SceneID) String() string {
+	switch id {
+	case SceneMenu:
+		return "menu"
+	case ScenePlaying:
+		return "playing"
+	case ScenePaused:
+		return "paused"
+	case SceneGameOver:
+		return "game over"
+	case SceneControls:
+		return "controls"
+	case SceneCampaign:
+		return "campaign"
+	case SceneLevelComplete:
+		return "level complete"
+	case SceneHighScores:
+		return "high scores"
+	case SceneNameEntry:
+		return "name entry"
+	case SceneSettings:
+		return "settings"
+	default:
+		return fmt.Sprintf("scene(%d)", int(id))
+	}
+}
+
+// Scene - экран игры. Enter и Exit вызываются при входе на экран и уходе с него,
+// Update - только у верхнего экрана стека, Draw - у всех снизу вверх,
+// поэтому пауза и итоги забега рисуются поверх игры
+type Scene interface {
+	ID() SceneID
+	Enter()
+	Exit()
+	Update()
+	Draw(screen *ebiten.Image)
+}
+
+// SceneMachine - стек экранов с проверкой разрешённых переходов
+type SceneMachine struct {
+	scenes      map[SceneID]Scene
+	transitions map[SceneID][]SceneID
+	stack       []Scene
+}
+
+func NewSceneMachine() *SceneMachine {
+	return &SceneMachine{
+		scenes:      make(map[SceneID]Scene),
+		transitions: make(map[SceneID][]SceneID),
+	}
+}
+
+// Register добавляет экран и список экранов, на которые с него можно перейти
+func (m *SceneMachine) Register(s Scene, to ...SceneID) {
+	m.scenes[s.ID()] = s
+	m.transitions[s.ID()] = to
+}
+
+// Current возвращает верхний экран стека
+func (m *SceneMachine) Current() Scene {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+// Is сообщает, является ли экран id верхним
+func (m *SceneMachine) Is(id SceneID) bool {
+	cur := m.Current()
+	return cur != nil && cur.ID() == id
+}
+
+func (m *SceneMachine) target(id SceneID) (Scene, error) {
+	next, ok := m.scenes[id]
+	if !ok {
+		return nil, fmt.Errorf("scene %v is not registered", id)
+	}
+
+	cur := m.Current()
+	if cur == nil {
+		return next, nil
+	}
+	for _, allowed := range m.transitions[cur.ID()] {
+		if allowed == id {
+			return next, nil
+		}
+	}
+	return nil, fmt.Errorf("transition %v -> %v is not allowed", cur.ID(), id)
+}
+
+// Switch закрывает все экраны стека и открывает id
+func (m *SceneMachine) Switch(id SceneID) error {
+	next, err := m.target(id)
+	if err != nil {
+		return err
+	}
+
+	for len(m.stack) > 0 {
+		m.Current().Exit()
+		m.stack = m.stack[:len(m.stack)-1]
+	}
+	m.stack = append(m.stack, next)
+	next.Enter()
+	return nil
+}
+
+// Push открывает id поверх текущего экрана
+func (m *SceneMachine) Push(id SceneID) error {
+	next, err := m.target(id)
+	if err != nil {
+		return err
+	}
+
+	m.stack = append(m.stack, next)
+	next.Enter()
+	return nil
+}
+
+// Replace заменяет верхний экран на id, не трогая экраны под ним
+func (m *SceneMachine) Replace(id SceneID) error {
+	next, err := m.target(id)
+	if err != nil {
+		return err
+	}
+
+	if cur := m.Current(); cur != nil {
+		cur.Exit()
+		m.stack = m.stack[:len(m.stack)-1]
+	}
+	m.stack = append(m.stack, next)
+	next.Enter()
+	return nil
+}
+
+// Pop закрывает верхний экран и возвращает к предыдущему
+func (m *SceneMachine) Pop() error {
+	if len(m.stack) < 2 {
+		return fmt.Errorf("no scene under %v", m.Current().ID())
+	}
+	if _, err := m.target(m.stack[len(m.stack)-2].ID()); err != nil {
+		return err
+	}
+
+	m.Current().Exit()
+	m.stack = m.stack[:len(m.stack)-1]
+	return nil
+}
+
+func (m *SceneMachine) Update() {
+	if cur := m.Current(); cur != nil {
+		cur.Update()
+	}
+}
+
+func (m *SceneMachine) Draw(screen *ebiten.Image) {
+	for _, s := range m.stack {
+		s.Draw(screen)
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Регистрация экранов игры и переходов между ними
+func (g *Game) createScenes() {
+	g.scenes = NewSceneMachine()
+	g.nameEntry = &nameEntryScene{g: g}
+	g.scenes.Register(&menuScene{g: g, buttons: NewButtonGroup(g.controls,
+		g.buttons["easy"], g.buttons["medium"], g.buttons["hard"], g.buttons["campaign"],
+		g.buttons["highscores"], g.buttons["settings"], g.buttons["exit_menu"],
+	)}, ScenePlaying, SceneSettings, SceneCampaign, SceneHighScores)
+	g.scenes.Register(&playScene{g}, ScenePaused, SceneGameOver, SceneLevelComplete, SceneNameEntry, SceneMenu)
+	g.scenes.Register(&pauseScene{g: g, buttons: NewButtonGroup(g.controls,
+		g.buttons["exit_pause"],
+	)}, ScenePlaying, SceneMenu)
+	g.scenes.Register(&gameOverScene{g: g, buttons: NewButtonGroup(g.controls,
+		g.buttons["restart"], g.buttons["menu"],
+	)}, ScenePlaying, SceneMenu)
+	g.scenes.Register(newSettingsScene(g), SceneMenu, SceneControls)
+	g.scenes.Register(newControlsScene(g), SceneSettings)
+	g.scenes.Register(&campaignScene{g: g}, ScenePlaying, SceneMenu)
+	g.scenes.Register(newLevelCompleteScene(g), ScenePlaying, SceneCampaign, SceneNameEntry, SceneMenu)
+	g.scenes.Register(newHighScoresScene(g), ScenePlaying, SceneMenu)
+	g.scenes.Register(g.nameEntry, SceneGameOver, SceneCampaign)
+}
+
+type menuScene struct {
+	g       *Game
+	buttons *ButtonGroup
+}
+
+func (s *menuScene) ID() SceneID               { return SceneMenu }
+func (s *menuScene) Enter()                    { s.buttons.Focus(0) }
+func (s *menuScene) Exit()                     {}
+func (s *menuScene) Update()                   { s.buttons.Update() }
+func (s *menuScene) Draw(screen *ebiten.Image) { s.g.drawMenu(screen) }
+
+type playScene struct{ g *Game }
+
+func (s *playScene) ID() SceneID { return ScenePlaying }
+
+func (s *playScene) Enter() {
+	s.g.lastUpdateTime = time.Now()
+}
+
+func (s *playScene) Exit() {}
+
+func (s *playScene) Update() {
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	// Обработка паузы
+	if s.g.controls.JustPressed(ActionPause) {
+		s.g.pushScene(ScenePaused)
+		return
+	}
+	s.g.updateGame()
+}
+
+func (s *playScene) Draw(screen *ebiten.Image) { s.g.drawGame(screen) }
+
+type pauseScene struct {
+	g       *Game
+	buttons *ButtonGroup
+}
+
+func (s *pauseScene) ID() SceneID { return ScenePaused }
+func (s *pauseScene) Enter()      { s.buttons.Focus(0) }
+
+// После паузы время не должно засчитываться в забег
+func (s *pauseScene) Exit() {
+	s.g.lastUpdateTime = time.Now()
+}
+
+func (s *pauseScene) Update() {
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	// Пауза проверяется раньше кнопок, поэтому пробел продолжает игру, а не нажимает кнопку
+	if s.g.controls.JustPressed(ActionPause) {
+		s.g.popScene()
+		return
+	}
+	s.buttons.Update()
+}
+
+func (s *pauseScene) Draw(screen *ebiten.Image) { s.g.drawPauseMenu(screen) }
+
+type gameOverScene struct {
+	g       *Game
+	buttons *ButtonGroup
+}
+
+func (s *gameOverScene) ID() SceneID { return SceneGameOver }
+
+func (s *gameOverScene) Enter() {
+	s.buttons.Focus(0)
+	s.g.finishRun()
+}
+
+func (s *gameOverScene) Exit() {}
+
+func (s *gameOverScene) Update() {
+	s.g.tickEffects()
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	s.buttons.Update()
+}
+
+func (s *gameOverScene) Draw(screen *ebiten.Image) { s.g.drawGameOver(screen) }
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"run-boy-run/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Шаг изменения громкости
+const volumeStep = 0.1
+
+// setting - строка экрана настроек: подпись со значением и его изменение
+// влево (-1) или вправо (+1); Enter и клик меняют вправо
+type setting struct {
+	label  func() string
+	change func(dir int)
+}
+
+// settingsScene - экран настроек игры
+type settingsScene struct {
+	g        *Game
+	settings []setting
+	rows     []*Button // По строке на каждую настройку
+	buttons  *ButtonGroup
+	changed  bool // Есть несохранённые изменения
+}
+
+func newSettingsScene(g *Game) *settingsScene {
+	s := &settingsScene{g: g}
+	s.settings = []setting{
+		{
+			label: func() string { return fmt.Sprintf("Window Scale: %dx", g.config.WindowScale) },
+			change: func(dir int) {
+				g.config.WindowScale = (g.config.WindowScale+dir+maxWindowScale-1)%maxWindowScale + 1
+				g.applyWindow()
+			},
+		},
+		{
+			label: func() string { return "Fullscreen: " + onOff(g.config.Fullscreen) },
+			change: func(int) {
+				g.config.Fullscreen = !g.config.Fullscreen
+				g.applyWindow()
+			},
+		},
+		{
+			label: func() string {
+				if g.config.PixelPerfect {
+					return "Scaling: Pixel Perfect"
+				}
+				return "Scaling: Smooth"
+			},
+			change: func(int) { g.config.PixelPerfect = !g.config.PixelPerfect },
+		},
+		{
+			label: func() string { return fmt.Sprintf("Music Volume: %.0f%%", g.audio.Settings().MusicVolume*100) },
+			change: func(dir int) {
+				a := g.audio.Settings()
+				a.MusicVolume = stepVolume(a.MusicVolume, dir)
+				g.audio.SetSettings(a)
+			},
+		},
+		{
+			label: func() string { return fmt.Sprintf("Effects Volume: %.0f%%", g.audio.Settings().SFXVolume*100) },
+			change: func(dir int) {
+				a := g.audio.Settings()
+				a.SFXVolume = stepVolume(a.SFXVolume, dir)
+				g.audio.SetSettings(a)
+				g.audio.Play(sfxStep) // Чтобы сразу услышать новую громкость
+			},
+		},
+		{
+			label:  func() string { return "Sound: " + onOff(!g.audio.Muted()) },
+			change: func(int) { g.audio.ToggleMute() },
+		},
+		{
+			label: func() string { return "Movement: " + g.config.Mode.String() },
+			change: func(int) {
+				if g.config.Mode == sim.MoveFree {
+					g.config.Mode = sim.MoveHop
+				} else {
+					g.config.Mode = sim.MoveFree
+				}
+			},
+		},
+		{
+			label: func() string { return "Palette: " + g.config.Palette.String() },
+			change: func(dir int) {
+				g.config.Palette = (g.config.Palette + Palette(dir) + paletteCount) % paletteCount
+			},
+		},
+	}
+
+	var all []*Button
+	for i, st := range s.settings {
+		st := st
+		row := &Button{
+			X:      ScreenWidth/2 - 150,
+			Y:      float64(86 + i*36),
+			Width:  300,
+			Height: 30,
+			Font:   Font,
+			Action: func() { s.change(st, 1) },
+		}
+		s.rows = append(s.rows, row)
+		all = append(all, row)
+	}
+
+	all = append(all, &Button{
+		X:      ScreenWidth/2 - 210,
+		Y:      380,
+		Width:  200,
+		Height: 40,
+		Text:   "Key Bindings",
+		Font:   Font,
+		Action: func() { g.switchScene(SceneControls) },
+	}, &Button{
+		X:      ScreenWidth/2 + 10,
+		Y:      380,
+		Width:  200,
+		Height: 40,
+		Text:   "Back",
+		Font:   Font,
+		Action: func() { g.switchScene(SceneMenu) },
+	})
+
+	s.buttons = NewButtonGroup(g.controls, all...)
+	return s
+}
+
+func (s *settingsScene) change(st setting, dir int) {
+	st.change(dir)
+	s.changed = true
+}
+
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+func stepVolume(v float64, dir int) float64 {
+	// Округление до шага, чтобы не копить ошибку float
+	steps := int(v/volumeStep+0.5) + dir
+	return clampVolume(float64(steps) * volumeStep)
+}
+
+func (s *settingsScene) ID() SceneID { return SceneSettings }
+
+func (s *settingsScene) Enter() {
+	s.changed = false
+	s.buttons.Focus(0)
+}
+
+// Настройки сохраняются один раз при уходе с экрана
+func (s *settingsScene) Exit() {
+	if s.changed {
+		s.g.saveConfig()
+	}
+}
+
+func (s *settingsScene) Update() {
+	for i, st := range s.settings {
+		s.rows[i].Text = st.label()
+	}
+
+	if s.g.controls.JustPressed(ActionBack) {
+		s.g.switchScene(SceneMenu)
+		return
+	}
+	// Влево и вправо меняют значение строки в фокусе, поэтому проверяются раньше кнопок
+	for i, row := range s.rows {
+		if !row.Focused {
+			continue
+		}
+		switch {
+		case s.g.controls.JustPressed(ActionLeft):
+			s.change(s.settings[i], -1)
+			return
+		case s.g.controls.JustPressed(ActionRight):
+			s.change(s.settings[i], 1)
+			return
+		}
+	}
+	s.buttons.Update()
+}
+
+func (s *settingsScene) Draw(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 180}, false)
+
+	title := "SETTINGS"
+	titleBounds := text.BoundString(Font, title)
+	text.Draw(screen, title, Font, ScreenWidth/2-titleBounds.Max.X/2, 60, color.RGBA{255, 215, 0, 255})
+
+	s.buttons.Draw(screen)
+
+	hint := "Up/Down: select   Left/Right or Enter: change"
+	hintBounds := text.BoundString(Font, hint)
+	text.Draw(screen, hint, Font, ScreenWidth/2-hintBounds.Max.X/2, ScreenHeight-30, color.RGBA{200, 200, 200, 255})
+}
@@ -0,0 +1,16 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Карта уровня под всеми объектами
+func (g *Game) drawTiles(screen *ebiten.Image) {
+	for r, row := range g.world.Map {
+		for c, t := range row {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(c*GridSize), float64(r*GridSize))
+			screen.DrawImage(g.assets.Sprite(tileSprite(t)), op)
+		}
+	}
+}
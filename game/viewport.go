@@ -0,0 +1,75 @@
+package game
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+// viewport - где на экране окна лежит кадр игры ScreenWidth x ScreenHeight.
+// Кадр масштабируется с сохранением пропорций, по краям остаются чёрные полосы
+type viewport struct {
+	scale      float64
+	offX, offY float64
+}
+
+// identityView - кадр один к одному, пока окно ещё не измерено
+var identityView = viewport{scale: 1}
+
+// newViewport вписывает кадр в экран w x h. В режиме pixelPerfect масштаб
+// целый, чтобы пиксели спрайтов оставались одинаковыми квадратами;
+// если окно меньше кадра, масштаб дробный в обоих режимах
+func newViewport(w, h float64, pixelPerfect bool) viewport {
+	scale := min(w/ScreenWidth, h/ScreenHeight)
+	if pixelPerfect && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	return viewport{
+		scale: scale,
+		offX:  math.Floor((w - ScreenWidth*scale) / 2),
+		offY:  math.Floor((h - ScreenHeight*scale) / 2),
+	}
+}
+
+// toLogical переводит точку экрана окна в координаты кадра игры
+func (v viewport) toLogical(x, y int) (int, int) {
+	return int(math.Floor((float64(x) - v.offX) / v.scale)), int(math.Floor((float64(y) - v.offY) / v.scale))
+}
+
+func (v viewport) geoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Scale(v.scale, v.scale)
+	m.Translate(v.offX, v.offY)
+	return m
+}
+
+// LayoutF отдаёт Ebiten экран размером с окно в физических пикселях,
+// а кадр игры рисуется в canvas и вписывается в него в Draw
+func (g *Game) LayoutF(outsideWidth, outsideHeight float64) (float64, float64) {
+	s := ebiten.Monitor().DeviceScaleFactor()
+	w, h := math.Ceil(outsideWidth*s), math.Ceil(outsideHeight*s)
+	g.view = newViewport(w, h, g.config.PixelPerfect)
+	return w, h
+}
+
+// Layout нужен для интерфейса ebiten.Game; Ebiten вызывает LayoutF
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	w, h := g.LayoutF(float64(outsideWidth), float64(outsideHeight))
+	return int(w), int(h)
+}
+
+// present вписывает готовый кадр canvas в экран окна
+func (g *Game) present(screen *ebiten.Image) {
+	filter := ebiten.FilterNearest
+	if !g.config.PixelPerfect && g.view.scale != math.Floor(g.view.scale) {
+		filter = ebiten.FilterLinear
+	}
+	if g.config.Palette != PaletteDefault {
+		op := &colorm.DrawImageOptions{GeoM: g.view.geoM(), Filter: filter}
+		colorm.DrawImage(screen, g.canvas, g.config.Palette.matrix(), op)
+		return
+	}
+	op := &ebiten.DrawImageOptions{GeoM: g.view.geoM(), Filter: filter}
+	screen.DrawImage(g.canvas, op)
+}
@@ -0,0 +1,38 @@
+// Package image содержит встроенные спрайты игры и их манифест
+// manifest.json. Файлы из каталога на диске подменяют встроенные
+// с тем же именем, так что спрайты можно менять без перекомпиляции.
+package image
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Скриншоты для README в игру не встраиваются
+//
+//go:embed manifest.json bus.png car.png motorcycle.png truck.png log.png log_long.png turtles.png player.png tiles.png
+//go:embed player_sheet.png car_anim.png truck_anim.png motorcycle_anim.png turtles_anim.png
+var files embed.FS
+
+// FS возвращает встроенные спрайты; если dir не пустой, файлы из dir важнее
+func FS(dir string) fs.FS {
+	if dir == "" {
+		return files
+	}
+	return overlay{top: os.DirFS(dir), bottom: files}
+}
+
+// overlay ищет файл сначала в top, затем в bottom
+type overlay struct {
+	top, bottom fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.bottom.Open(name)
+	}
+	return f, err
+}
@@ -0,0 +1,81 @@
+// Package levels содержит встроенные файлы уровней. Уровни из
+// каталога на диске добавляются к ним или заменяют одноимённые,
+// так что новые уровни не требуют перекомпиляции.
+package levels
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"run-boy-run/sim"
+)
+
+//go:embed *.json campaign/*.json
+var files embed.FS
+
+// Подкаталог с уровнями кампании; они проходятся по порядку имён файлов
+const campaignDir = "campaign"
+
+// Set - все загруженные уровни
+type Set struct {
+	Levels   map[string]*sim.Level // По ID, включая уровни кампании
+	Campaign []*sim.Level          // Уровни кампании по порядку
+}
+
+// Load загружает встроенные уровни, а если dir не пустой - ещё и уровни из dir
+func Load(dir string) (*Set, error) {
+	set := &Set{Levels: make(map[string]*sim.Level)}
+	if err := set.add(files); err != nil {
+		return nil, fmt.Errorf("built-in levels: %w", err)
+	}
+	if dir != "" {
+		if err := set.add(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("levels in %s: %w", dir, err)
+		}
+	}
+
+	var ids []string
+	for id := range set.Levels {
+		if strings.HasPrefix(id, campaignDir+"/") {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		set.Campaign = append(set.Campaign, set.Levels[id])
+	}
+	return set, nil
+}
+
+// Level возвращает уровень по ID или nil
+func (s *Set) Level(id string) *sim.Level {
+	return s.Levels[id]
+}
+
+func (s *Set) add(fsys fs.FS) error {
+	levels, err := sim.LoadLevels(fsys)
+	if err != nil {
+		return err
+	}
+	for id, l := range levels {
+		s.Levels[id] = l
+	}
+
+	sub, err := fs.Sub(fsys, campaignDir)
+	if err != nil {
+		return err
+	}
+	campaign, err := sim.LoadLevels(sub)
+	if err != nil {
+		return fmt.Errorf("%s: %w", campaignDir, err)
+	}
+	for id, l := range campaign {
+		l.ID = campaignDir + "/" + id
+		s.Levels[l.ID] = l
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package replay записывает ввод игрока по шагам симуляции и
+// воспроизводит его. Вместе с seed и сложностью этого достаточно,
+// чтобы в точности повторить забег.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"run-boy-run/sim"
+)
+
+// Заголовок файла: magic, версия формата
+var magic = [4]byte{'R', 'B', 'R', 'P'}
+
+// Версия 2 добавила режим движения, версия 3 - уровень, версия 4 - серию побед,
+// версия 5 - версию правил, версия 6 - отпечаток уровня. Файлы версии 1 читаются
+// как MoveFree, до версии 3 - без уровня, до 4 - без серии, до 5 - с правилами
+// версии 0, до 6 - без отпечатка
+const version = 6
+
+// Длина ID уровня хранится байтом
+const maxLevelID = 255
+
+// Забег не длиннее самого долгого уровня: больше шагов в файле быть не может
+const maxTicks = sim.MaxTimeLimit * sim.TickRate
+
+// Биты состояния клавиш в одном байте
+const (
+	bitLeft = 1 << iota
+	bitRight
+	bitUp
+	bitDown
+)
+
+type Replay struct {
+	Seed       int64
+	Difficulty int
+	Level      string // ID уровня; пустой у старых файлов
+	LevelHash  uint64 // sim.Level.Hash уровня, на котором записан забег
+	Mode       sim.MovementMode
+	Streak     int         // Влияет на множитель очков
+	Rules      int         // sim.RulesVersion, по которым записан забег
+	Inputs     []sim.Input // Ввод на каждый шаг симуляции
+}
+
+func New(cfg sim.Config) *Replay {
+	r := &Replay{Seed: cfg.Seed, Difficulty: cfg.Difficulty, Mode: cfg.Mode, Streak: cfg.Streak, Rules: sim.RulesVersion}
+	if cfg.Level != nil {
+		r.Level = cfg.Level.ID
+		r.LevelHash = cfg.Level.Hash()
+	}
+	return r
+}
+
+// CheckLevel сообщает, если уровень l не тот, на котором записан забег:
+// его файл изменился или подменён через -levels, и воспроизведение разошлось бы
+func (r *Replay) CheckLevel(l *sim.Level) error {
+	if l.Hash() != r.LevelHash {
+		return fmt.Errorf("replay was recorded on another version of level %q", l.ID)
+	}
+	return nil
+}
+
+// Config возвращает конфигурацию мира, в котором был записан забег.
+// Уровень по r.Level подставляет вызывающий, проверив его CheckLevel
+func (r *Replay) Config() sim.Config {
+	return sim.Config{Difficulty: r.Difficulty, Seed: r.Seed, Mode: r.Mode, Streak: r.Streak}
+}
+
+// Record добавляет ввод очередного шага
+func (r *Replay) Record(in sim.Input) {
+	r.Inputs = append(r.Inputs, in)
+}
+
+func encodeInput(in sim.Input) byte {
+	var b byte
+	if in.Left {
+		b |= bitLeft
+	}
+	if in.Right {
+		b |= bitRight
+	}
+	if in.Up {
+		b |= bitUp
+	}
+	if in.Down {
+		b |= bitDown
+	}
+	return b
+}
+
+func decodeInput(b byte) sim.Input {
+	return sim.Input{
+		Left:  b&bitLeft != 0,
+		Right: b&bitRight != 0,
+		Up:    b&bitUp != 0,
+		Down:  b&bitDown != 0,
+	}
+}
+
+// Encode пишет реплей в компактном виде: ввод хранится сериями
+// (байт состояния, сколько шагов подряд он держался)
+func (r *Replay) Encode(w io.Writer) error {
+	if len(r.Level) > maxLevelID {
+		return fmt.Errorf("level ID %q is longer than %d bytes", r.Level, maxLevelID)
+	}
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, 4+3+len(r.Level)+8+binary.MaxVarintLen64*5)
+	header = append(header, magic[:]...)
+	header = append(header, version, byte(r.Difficulty), byte(r.Mode))
+	header = binary.AppendUvarint(header, uint64(len(r.Level)))
+	header = append(header, r.Level...)
+	header = binary.LittleEndian.AppendUint64(header, r.LevelHash)
+	header = binary.AppendUvarint(header, uint64(r.Streak))
+	header = binary.AppendUvarint(header, uint64(r.Rules))
+	header = binary.AppendVarint(header, r.Seed)
+	header = binary.AppendUvarint(header, uint64(len(r.Inputs)))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64)
+	for i := 0; i < len(r.Inputs); {
+		cur := encodeInput(r.Inputs[i])
+		run := 1
+		for i+run < len(r.Inputs) && encodeInput(r.Inputs[i+run]) == cur {
+			run++
+		}
+		buf = append(buf[:0], cur)
+		buf = binary.AppendUvarint(buf, uint64(run))
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		i += run
+	}
+
+	return bw.Flush()
+}
+
+// Decode читает реплей. Реплей, записанный по другим правилам, не читается:
+// воспроизведение разошлось бы с записанным забегом
+func Decode(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+
+	var head [6]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if [4]byte(head[:4]) != magic {
+		return nil, errors.New("not a replay file")
+	}
+	if head[4] < 1 || head[4] > version {
+		return nil, fmt.Errorf("unsupported replay version %d", head[4])
+	}
+
+	cfg := sim.Config{Difficulty: int(head[5])}
+	if head[4] >= 2 {
+		mode, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		cfg.Mode = sim.MovementMode(mode)
+	}
+	var level string
+	if head[4] >= 3 {
+		n, err := binary.ReadUvarint(br)
+		if err != nil || n > maxLevelID {
+			return nil, errors.New("read header: bad level name")
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		level = string(name)
+	}
+	var levelHash [8]byte
+	if head[4] >= 6 {
+		if _, err := io.ReadFull(br, levelHash[:]); err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+	}
+	if head[4] >= 4 {
+		streak, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		cfg.Streak = int(streak)
+	}
+	var rules uint64
+	if head[4] >= 5 {
+		var err error
+		if rules, err = binary.ReadUvarint(br); err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+	}
+	if rules != sim.RulesVersion {
+		return nil, fmt.Errorf("replay was recorded with game rules version %d, this game uses version %d", rules, sim.RulesVersion)
+	}
+
+	seed, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("read seed: %w", err)
+	}
+	total, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("read length: %w", err)
+	}
+	if total > maxTicks {
+		return nil, fmt.Errorf("replay claims %d ticks, longer than any level (%d)", total, maxTicks)
+	}
+
+	cfg.Seed = seed
+	rep := New(cfg)
+	rep.Level = level
+	rep.LevelHash = binary.LittleEndian.Uint64(levelHash[:])
+	for uint64(len(rep.Inputs)) < total {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read input at tick %d: %w", len(rep.Inputs), err)
+		}
+		run, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("read input at tick %d: %w", len(rep.Inputs), err)
+		}
+		if run == 0 || uint64(len(rep.Inputs))+run > total {
+			return nil, fmt.Errorf("corrupt input run at tick %d", len(rep.Inputs))
+		}
+		in := decodeInput(b)
+		for ; run > 0; run-- {
+			rep.Inputs = append(rep.Inputs, in)
+		}
+	}
+
+	return rep, nil
+}
+
+func Save(path string, r *Replay) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func Load(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rep, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rep, nil
+}
+
+// Player выдаёт записанный ввод шаг за шагом
+type Player struct {
+	replay *Replay
+	pos    int
+}
+
+func NewPlayer(r *Replay) *Player {
+	return &Player{replay: r}
+}
+
+// Next возвращает ввод следующего шага; когда запись кончилась,
+// возвращает пустой ввод и false
+func (p *Player) Next() (sim.Input, bool) {
+	if p.pos >= len(p.replay.Inputs) {
+		return sim.Input{}, false
+	}
+	in := p.replay.Inputs[p.pos]
+	p.pos++
+	return in, true
+}
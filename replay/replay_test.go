@@ -0,0 +1,148 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"run-boy-run/sim"
+)
+
+func TestRoundTrip(t *testing.T) {
+	up := sim.Input{Up: true}
+	all := sim.Input{Left: true, Right: true, Up: true, Down: true}
+	long := make([]sim.Input, 10*sim.TickRate)
+	for i := range long {
+		long[i] = sim.Input{Up: i%90 < 60, Left: i%300 >= 250}
+	}
+	tests := []struct {
+		name   string
+		replay *Replay
+	}{
+		{"empty", &Replay{Seed: 1, Rules: sim.RulesVersion}},
+		{"one tick", &Replay{Seed: 2, Rules: sim.RulesVersion, Inputs: []sim.Input{up}}},
+		{"every key", &Replay{Seed: 3, Rules: sim.RulesVersion, Inputs: []sim.Input{{}, {Left: true}, {Right: true}, up, {Down: true}, all}}},
+		{"long runs", &Replay{Seed: 4, Rules: sim.RulesVersion, Inputs: long}},
+		{"header", &Replay{Seed: -1 << 40, Difficulty: sim.Hard, Level: "campaign/06-river-crossing", LevelHash: 0x0123456789abcdef, Mode: sim.MoveHop, Streak: 7, Rules: sim.RulesVersion, Inputs: []sim.Input{up, up}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.replay.Encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.replay) {
+				t.Errorf("decoded %+v, want %+v", got, tt.replay)
+			}
+		})
+	}
+}
+
+// header - заголовок файла версии v с правилами rules и длиной total шагов
+func header(v byte, rules, total uint64) []byte {
+	h := append(magic[:], v, 0, 0, 0)
+	if v >= 6 {
+		h = append(h, make([]byte, 8)...)
+	}
+	h = append(h, 0)
+	if v >= 5 {
+		h = binary.AppendUvarint(h, rules)
+	}
+	h = binary.AppendVarint(h, 1)
+	return binary.AppendUvarint(h, total)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	(&Replay{Seed: 5, Rules: sim.RulesVersion, Inputs: []sim.Input{{Up: true}, {Up: true}, {}}}).Encode(&buf)
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "read header"},
+		{"magic", append([]byte("RIFF"), valid[4:]...), "not a replay file"},
+		{"future version", append(append([]byte{}, valid[:4]...), append([]byte{version + 1}, valid[5:]...)...), "unsupported replay version"},
+		{"truncated", valid[:len(valid)-1], "read input at tick 2"},
+		{"truncated level hash", append(append([]byte{}, magic[:]...), version, 0, 0, 0, 1, 2, 3), "read header"},
+		{"zero run", append(append([]byte{}, valid[:len(valid)-1]...), 0), "corrupt input run at tick 2"},
+		{"run past the end", append(header(version, sim.RulesVersion, 2), 0, 3), "corrupt input run at tick 0"},
+		// Файл в пару десятков байт не должен заставлять выделять память под миллиарды шагов
+		{"too long", append(header(version, sim.RulesVersion, 1<<34), 0, 0x80, 0x80, 0x80, 0x80, 0x40), "longer than any level"},
+		{"other rules", header(version, sim.RulesVersion+1, 0), "rules version"},
+		{"before rules versions", header(4, 0, 0), "rules version 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeLongLevelID(t *testing.T) {
+	r := &Replay{Level: strings.Repeat("x", maxLevelID), Rules: sim.RulesVersion}
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatalf("ID of %d bytes: %v", maxLevelID, err)
+	}
+	if _, err := Decode(&buf); err != nil {
+		t.Fatalf("decode ID of %d bytes: %v", maxLevelID, err)
+	}
+	// Такой файл не прочитался бы, поэтому он не пишется
+	r.Level += "x"
+	if err := r.Encode(io.Discard); err == nil || !strings.Contains(err.Error(), "longer than 255 bytes") {
+		t.Errorf("error = %v, want a too long level ID", err)
+	}
+}
+
+func TestCheckLevel(t *testing.T) {
+	level := func() *sim.Level {
+		return &sim.Level{ID: "test", Name: "Test", TimeLimit: 30, Start: sim.Point{X: 320, Y: 448}, Goal: sim.Zone{Height: 5},
+			Lanes: []sim.LaneDef{{Y: 416, Direction: sim.DirLeft, Vehicle: "car", Count: 2, GapMin: 4, GapMax: 6}}}
+	}
+	r := New(sim.Config{Level: level(), Seed: 1})
+	tests := []struct {
+		name    string
+		edit    func(l *sim.Level)
+		wantErr bool
+	}{
+		{"same level", func(*sim.Level) {}, false},
+		{"renamed file", func(l *sim.Level) { l.ID = "copy" }, false},
+		{"other time limit", func(l *sim.Level) { l.TimeLimit = 20 }, true},
+		{"faster lane", func(l *sim.Level) { l.Lanes[0].SpeedMin, l.Lanes[0].SpeedMax = 3, 4 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := level()
+			tt.edit(l)
+			if err := r.CheckLevel(l); (err != nil) != tt.wantErr {
+				t.Errorf("CheckLevel() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	r := &Replay{Inputs: []sim.Input{{Up: true}, {Left: true}}}
+	p := NewPlayer(r)
+	for i, want := range r.Inputs {
+		if in, ok := p.Next(); !ok || in != want {
+			t.Errorf("tick %d: Next() = %v, %v, want %v, true", i, in, ok, want)
+		}
+	}
+	if in, ok := p.Next(); ok || in != (sim.Input{}) {
+		t.Errorf("after the end Next() = %v, %v, want empty input and false", in, ok)
+	}
+}
@@ -0,0 +1,38 @@
+package sim
+
+// Частота шагов симуляции
+const (
+	TickRate     = 60
+	TickDuration = 1.0 / TickRate
+)
+
+// Не больше стольких шагов за один кадр, чтобы не уйти в догонялки после зависания
+const maxStepsPerFrame = 8
+
+// FixedStep накапливает реальное время и превращает его в целое число
+// шагов фиксированной длины TickDuration
+type FixedStep struct {
+	acc float64
+}
+
+// Advance добавляет прошедшее время и возвращает, сколько шагов нужно сделать
+func (f *FixedStep) Advance(elapsed float64) int {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	f.acc += elapsed
+
+	steps := 0
+	for f.acc >= TickDuration && steps < maxStepsPerFrame {
+		f.acc -= TickDuration
+		steps++
+	}
+	if steps == maxStepsPerFrame {
+		f.acc = 0
+	}
+	return steps
+}
+
+func (f *FixedStep) Reset() {
+	f.acc = 0
+}
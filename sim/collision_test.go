@@ -0,0 +1,178 @@
+package sim
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"math/rand"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bundledLevels - уровни из каталога levels, включая кампанию
+func bundledLevels(t testing.TB) []*Level {
+	var all []*Level
+	for _, dir := range []string{"../levels", "../levels/campaign"} {
+		set, err := LoadLevels(os.DirFS(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range slices.Sorted(maps.Keys(set)) {
+			all = append(all, set[id])
+		}
+	}
+	return all
+}
+
+// naive заменяет поиск по полосам перебором всех машин
+func naive(w *World) {
+	w.broadPhase = func(Rect) iter.Seq[*GameObject] { return slices.Values(w.Cars) }
+}
+
+// Поиск по полосам - только ускорение: с ним и с перебором всех машин
+// забеги должны совпадать до события
+func TestBroadPhaseMatchesNaive(t *testing.T) {
+	seeds := int64(200)
+	if testing.Short() {
+		seeds = 20
+	}
+	levels := append(bundledLevels(t), busyLevel())
+	hits, nearMisses := 0, 0
+	for _, l := range levels {
+		for _, mode := range []MovementMode{MoveFree, MoveHop} {
+			for seed := int64(1); seed <= seeds; seed++ {
+				cfg := Config{Level: l, Seed: seed, Mode: mode}
+				inputs := randomInputs(seed, l.TimeLimit*TickRate)
+				lanes := runWorld(NewWorld(cfg), inputs)
+				w := NewWorld(cfg)
+				naive(w)
+				if want := runWorld(w, inputs); !reflect.DeepEqual(lanes, want) {
+					t.Fatalf("%s, %v, seed %d: broad-phase run differs from the naive one:\n%+v\n%+v", l.Name, mode, seed, lanes, want)
+				}
+				for _, ev := range lanes.Events {
+					switch ev.Kind {
+					case EventHit, EventLifeLost:
+						hits++
+					case EventNearMiss:
+						nearMisses++
+					}
+				}
+			}
+		}
+	}
+	// Без столкновений и сближений сравнение ничего бы не проверило
+	if hits == 0 || nearMisses == 0 {
+		t.Errorf("runs had %d hits and %d near misses, want both", hits, nearMisses)
+	}
+}
+
+// Столкновение на шаге снимает отметки сближения со всех машин, даже тех,
+// до которых проверка не дошла
+func TestHitClearsNearMisses(t *testing.T) {
+	l := testLevel(emptyLane(448, LaneRoad, "bus"), emptyLane(416, LaneRoad, "car"))
+	l.Lives = 2
+	w := NewWorld(Config{Level: l, Seed: 1})
+	bus := place(w, 0, "bus", 320+GridSize+4)
+	car := place(w, 1, "car", 300)
+	w.Step(TickDuration, Input{})
+	if !bus.nearMiss || !car.nearMiss {
+		t.Fatalf("near-miss flags bus %v, car %v, want both set", bus.nearMiss, car.nearMiss)
+	}
+
+	bus.X = 300
+	if events := w.Step(TickDuration, Input{}); lastEvent(events) != EventLifeLost {
+		t.Fatalf("events = %v, want EventLifeLost", events)
+	}
+	if bus.nearMiss || car.nearMiss || len(w.near) != 0 {
+		t.Errorf("near-miss flags bus %v, car %v, %d cars listed after a hit, want none", bus.nearMiss, car.nearMiss, len(w.near))
+	}
+}
+
+// crowdLevel - дороги во весь экран, кроме строки посередине, где стоит игрок:
+// он не погибает, но машины соседних полос проверяются на каждом шаге
+func crowdLevel() *Level {
+	l := testLevel()
+	l.TimeLimit = MaxTimeLimit
+	l.Start.Y = 7 * GridSize
+	for row := 0; row < GridHeight; row++ {
+		if row == 7 {
+			continue
+		}
+		l.Lanes = append(l.Lanes, LaneDef{
+			Y: float64(row * GridSize), Direction: DirRandom,
+			Vehicles: []string{"motorcycle", "car", "bus", "truck"}, GapMin: 4, GapMax: 4,
+		})
+	}
+	return l
+}
+
+// crowd добавляет на полосы n машин: за краем въезда выстраивается очередь,
+// которая въезжает на экран дольше, чем идёт замер
+func crowd(w *World, n int) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		lane := w.Lanes[i%len(w.Lanes)]
+		name := pickVehicle(lane.def, rng)
+		var x float64
+		switch last := len(lane.Cars) - 1; {
+		case last < 0 && lane.IsRight:
+			x = -float64(Vehicles[name].Width)
+		case last < 0:
+			x = ScreenWidth
+		case lane.IsRight:
+			x = lane.Cars[last].X - float64(Vehicles[name].Width+MinVehicleGap)
+		default:
+			x = lane.Cars[last].X + float64(lane.Cars[last].Width+MinVehicleGap)
+		}
+		lane.Cars = append(lane.Cars, lane.newCar(name, x))
+	}
+	w.collectObjects()
+}
+
+// benchSteps гоняет мир, который строит newWorld; закончившийся забег
+// или опустевшая очередь машин заменяются новым миром вне замера
+func benchSteps(b *testing.B, newWorld func() *World, cars int) {
+	w := newWorld()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if w.Outcome != Running || len(w.Cars) < cars/2 {
+			b.StopTimer()
+			w = newWorld()
+			b.StartTimer()
+		}
+		w.Step(TickDuration, Input{})
+	}
+}
+
+// Шаг мира с тысячами машин: поиск по полосам против перебора всех машин
+func BenchmarkStepCrowd(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		for _, method := range []string{"lanes", "naive"} {
+			b.Run(fmt.Sprintf("cars=%d/%s", n, method), func(b *testing.B) {
+				benchSteps(b, func() *World {
+					w := NewWorld(Config{Level: crowdLevel(), Seed: 1})
+					crowd(w, n)
+					if method == "naive" {
+						naive(w)
+					}
+					return w
+				}, n)
+			})
+		}
+	}
+}
+
+// Шаг мира на встроенных уровнях; игрок стоит на старте
+func BenchmarkStepLevels(b *testing.B) {
+	for _, l := range bundledLevels(b) {
+		b.Run(l.Name, func(b *testing.B) {
+			seed := int64(0)
+			benchSteps(b, func() *World {
+				seed++
+				return NewWorld(Config{Level: l, Seed: seed})
+			}, 0)
+		})
+	}
+}
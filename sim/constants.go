@@ -0,0 +1,19 @@
+package sim
+
+const (
+	ScreenWidth    = 640
+	ScreenHeight   = 480
+	GridSize       = 32
+	GridWidth      = ScreenWidth / GridSize
+	GridHeight     = ScreenHeight / GridSize
+	PlayerSpeed    = 5
+	LaneSpacing    = GridSize * 1.5 // Расстояние между полосами
+	TextAreaHeight = 5              // Высота области для текста
+)
+
+// Константы для уровней сложности
+const (
+	Easy = iota
+	Medium
+	Hard
+)
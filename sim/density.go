@@ -0,0 +1,32 @@
+package sim
+
+// DensityPoint - точка кривой плотности движения
+type DensityPoint struct {
+	At   float64 `json:"at"`   // Доля прошедшего времени уровня, 0..1
+	Rate float64 `json:"rate"` // Во сколько раз чаще обычного появляются машины
+}
+
+// Кривая по умолчанию: к концу времени машин вдвое больше
+var defaultDensity = []DensityPoint{{At: 0, Rate: 1}, {At: 1, Rate: 2}}
+
+// density возвращает плотность в момент at с линейной интерполяцией между точками
+func density(curve []DensityPoint, at float64) float64 {
+	if len(curve) == 0 {
+		curve = defaultDensity
+	}
+	if at <= curve[0].At {
+		return curve[0].Rate
+	}
+	for i := 1; i < len(curve); i++ {
+		a, b := curve[i-1], curve[i]
+		if at <= b.At {
+			return a.Rate + (b.Rate-a.Rate)*(at-a.At)/(b.At-a.At)
+		}
+	}
+	return curve[len(curve)-1].Rate
+}
+
+// Плотность движения на текущем шаге
+func (w *World) density() float64 {
+	return density(w.Level.Density, float64(w.Tick)/float64(w.LevelTime*TickRate))
+}
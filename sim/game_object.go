@@ -0,0 +1,54 @@
+package sim
+
+// GameObject - движущийся объект мира (игрок, машина или плот) без привязки к отрисовке
+type GameObject struct {
+	X, Y    float64
+	Speed   float64
+	Width   int
+	Height  int
+	IsRight bool
+	Vehicle string // Тип из Vehicles; у игрока пусто
+	Shape   Shape  // Форма для столкновений; нулевая - весь объект
+
+	Sinks     bool // Периодически уходит под воду (черепахи)
+	Submerged bool // Сейчас под водой, стоять на нём нельзя
+
+	nearMiss bool // Была рядом с игроком; очки за сближение, когда отъедет
+	nearTick int  // Шаг, на котором сближение проверялось последним
+	sinkTick int  // Шаг цикла погружения
+}
+
+// Bounds - прямоугольник объекта целиком
+func (g *GameObject) Bounds() Rect {
+	return Rect{g.X, g.Y, g.X + float64(g.Width), g.Y + float64(g.Height)}
+}
+
+// Collider - форма для столкновений в точке, где сейчас объект
+func (g *GameObject) Collider() Collider {
+	return g.colliderAt(g.X, g.Y)
+}
+
+func (g *GameObject) colliderAt(x, y float64) Collider {
+	s := g.Shape
+	if s.Bounds.Empty() {
+		s = InsetRect(g.Width, g.Height, 0, 0, 0, 0)
+	}
+	return Collider{Shape: s, X: x, Y: y}
+}
+
+// Velocity - скорость по горизонтали в пикселях в секунду, вправо положительная
+func (g *GameObject) Velocity() float64 {
+	if g.IsRight {
+		return g.Speed * float64(GridSize)
+	}
+	return -g.Speed * float64(GridSize)
+}
+
+// Update сдвигает объект по горизонтали и ведёт цикл погружения
+func (g *GameObject) Update(elapsed float64) {
+	g.X += g.Velocity() * elapsed
+	if g.Sinks {
+		g.sinkTick = (g.sinkTick + 1) % SinkCycleTicks
+		g.Submerged = g.sinkTick >= SinkCycleTicks-SinkDownTicks
+	}
+}
@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// MinVehicleGap - наименьший просвет между машинами одной полосы,
+// чтобы между ними всегда мог пройти игрок
+const MinVehicleGap = GridSize
+
+// Lane - полоса движения: дорога с машинами или река с плотами. Все её машины едут в одну сторону с одной скоростью,
+// поэтому просвет между ними, заданный при появлении, дальше не меняется.
+// Новые машины въезжают с края экрана, уехавшие за другой край удаляются
+type Lane struct {
+	Y       float64
+	IsRight bool
+	Water   bool          // Река: вода смертельна, машины - плоты, на которых стоят
+	Speed   float64       // Клеток в секунду
+	Cars    []*GameObject // В порядке появления: первая ближе всех к выезду
+	def     LaneDef
+	spawnIn int    // Шагов до следующей машины
+	next    string // Тип следующей машины
+}
+
+func newLane(def LaneDef, rng *rand.Rand) *Lane {
+	l := &Lane{
+		Y:       def.Y,
+		IsRight: def.Direction == DirRight,
+		Water:   def.Kind == LaneWater,
+		def:     def,
+	}
+	if def.Direction == DirRandom {
+		l.IsRight = rng.Intn(2) == 0
+	}
+	speedMin, speedMax := def.speedRange()
+	l.Speed = speedMin + rng.Float64()*(speedMax-speedMin)
+
+	// Начальные машины расставляются по всей дороге, чтобы уровень не начинался пустым
+	var last *GameObject
+	lastX := -float64(GridSize)
+	for i := 0; i < def.Count; i++ {
+		name := pickVehicle(def, rng)
+		x := lastX + (def.GapMin+rng.Float64()*(def.GapMax-def.GapMin))*GridSize
+		if last != nil {
+			x = max(x, last.X+float64(last.Width)+MinVehicleGap)
+		}
+		if x >= ScreenWidth {
+			break
+		}
+		last = l.newCar(name, x)
+		lastX = x
+		l.Cars = append(l.Cars, last)
+	}
+	// Машины вправо выезжают справа, значит первой должна быть самая правая
+	if l.IsRight {
+		for i, j := 0, len(l.Cars)-1; i < j; i, j = i+1, j-1 {
+			l.Cars[i], l.Cars[j] = l.Cars[j], l.Cars[i]
+		}
+	}
+
+	l.schedule(rng, 1)
+	return l
+}
+
+func (l *Lane) newCar(name string, x float64) *GameObject {
+	v := Vehicles[name]
+	return &GameObject{
+		X:       x,
+		Y:       l.Y,
+		Speed:   l.Speed,
+		Width:   v.Width,
+		Height:  v.Height,
+		IsRight: l.IsRight,
+		Vehicle: name,
+		Shape:   v.Shape,
+		Sinks:   v.Sinks,
+	}
+}
+
+// Тип очередной машины полосы. Случайное число тратится только при выборе
+// из нескольких типов, чтобы полосы с одним типом не сдвигали последовательность
+func pickVehicle(def LaneDef, rng *rand.Rand) string {
+	types := def.VehicleTypes()
+	if len(types) == 1 {
+		return types[0]
+	}
+	return types[rng.Intn(len(types))]
+}
+
+// Выбор следующей машины и интервала до неё. Промежуток из gap_min..gap_max
+// переводится во время при скорости полосы; плотность density его сокращает
+func (l *Lane) schedule(rng *rand.Rand, density float64) {
+	l.next = pickVehicle(l.def, rng)
+	gap := l.def.GapMin + rng.Float64()*(l.def.GapMax-l.def.GapMin)
+	l.spawnIn = int(math.Ceil(gap / l.Speed / density * TickRate))
+}
+
+// Update двигает машины, убирает уехавшие и выпускает новые
+func (l *Lane) Update(dt float64, rng *rand.Rand, density float64) {
+	for _, car := range l.Cars {
+		car.Update(dt)
+	}
+	for len(l.Cars) > 0 && l.gone(l.Cars[0]) {
+		l.Cars = l.Cars[1:]
+	}
+
+	if l.spawnIn > 0 {
+		l.spawnIn--
+	}
+	if l.spawnIn == 0 && l.canSpawn() {
+		x := float64(ScreenWidth)
+		if l.IsRight {
+			x = -float64(Vehicles[l.next].Width)
+		}
+		l.Cars = append(l.Cars, l.newCar(l.next, x))
+		l.schedule(rng, density)
+	}
+}
+
+// Машина целиком за краем выезда
+func (l *Lane) gone(car *GameObject) bool {
+	if l.IsRight {
+		return car.X >= ScreenWidth
+	}
+	return car.X+float64(car.Width) <= 0
+}
+
+// Новая машина появляется только после того, как предыдущая отъехала
+// от края хотя бы на MinVehicleGap; иначе выпуск откладывается
+func (l *Lane) canSpawn() bool {
+	if len(l.Cars) == 0 {
+		return true
+	}
+	last := l.Cars[len(l.Cars)-1]
+	if l.IsRight {
+		return last.X >= MinVehicleGap
+	}
+	return last.X+float64(last.Width) <= ScreenWidth-MinVehicleGap
+}
+
+// Overlaps сообщает, задевает ли r полосу по вертикали. Все машины высотой в клетку
+func (l *Lane) Overlaps(r Rect) bool {
+	return l.Y < r.Y1 && r.Y0 < l.Y+GridSize
+}
+
+// Near возвращает машины полосы, которые по X задевают отрезок [x0, x1).
+// Машины полосы не перекрываются и идут по X (вправо - по убыванию), поэтому
+// хватает двоичного поиска, сколько бы машин ни было на полосе
+func (l *Lane) Near(x0, x1 float64) []*GameObject {
+	cars := l.Cars
+	right := func(i int) float64 { return cars[i].X + float64(cars[i].Width) }
+	if l.IsRight {
+		i := sort.Search(len(cars), func(i int) bool { return cars[i].X < x1 })
+		j := sort.Search(len(cars), func(i int) bool { return right(i) <= x0 })
+		return cars[i:j]
+	}
+	i := sort.Search(len(cars), func(i int) bool { return right(i) > x0 })
+	j := sort.Search(len(cars), func(i int) bool { return cars[i].X >= x1 })
+	return cars[i:j]
+}
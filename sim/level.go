@@ -0,0 +1,261 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+)
+
+// Направления движения машин на полосе
+const (
+	DirLeft   = "left"
+	DirRight  = "right"
+	DirRandom = "random" // Направление полосы выбирается при старте забега
+)
+
+// Виды полос
+const (
+	LaneRoad  = "road"
+	LaneWater = "water"
+)
+
+// LaneDef - описание одной полосы в файле уровня
+type LaneDef struct {
+	Kind      string   `json:"kind,omitempty"` // road (по умолчанию) или water
+	Y         float64  `json:"y"`              // Верх полосы в пикселях
+	Direction string   `json:"direction"`      // left, right или random
+	SpeedMin  float64  `json:"speed_min"`      // Клеток в секунду; 0 - скорость из каталога
+	SpeedMax  float64  `json:"speed_max"`
+	Vehicle   string   `json:"vehicle,omitempty"`  // Один тип транспорта
+	Vehicles  []string `json:"vehicles,omitempty"` // Или несколько, выбираются случайно
+	Count     int      `json:"count"`              // Машин на полосе при старте
+	GapMin    float64  `json:"gap_min"`            // Расстояние между машинами в клетках
+	GapMax    float64  `json:"gap_max"`
+}
+
+// Диапазон скорости полосы. Без явного диапазона полоса едет
+// со скоростью самого медленного своего типа
+func (l LaneDef) speedRange() (float64, float64) {
+	if l.SpeedMax != 0 {
+		return l.SpeedMin, l.SpeedMax
+	}
+	lo, hi := 0.0, 0.0
+	for i, name := range l.VehicleTypes() {
+		v := Vehicles[name]
+		if i == 0 || v.SpeedMax < hi {
+			lo, hi = v.SpeedMin, v.SpeedMax
+		}
+	}
+	return lo, hi
+}
+
+// VehicleTypes возвращает типы транспорта полосы
+func (l LaneDef) VehicleTypes() []string {
+	if l.Vehicle != "" {
+		return append([]string{l.Vehicle}, l.Vehicles...)
+	}
+	return l.Vehicles
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Zone - горизонтальная полоса экрана
+type Zone struct {
+	Y      float64 `json:"y"`
+	Height float64 `json:"height"`
+}
+
+// MaxTimeLimit - самое большое время уровня в секундах; оно же ограничивает длину реплея
+const MaxTimeLimit = 10 * 60
+
+// Level - описание уровня, загружаемое из JSON
+type Level struct {
+	ID        string    `json:"-"` // Имя файла без расширения
+	Name      string    `json:"name"`
+	TimeLimit int       `json:"time_limit"` // Секунд на прохождение
+	Lives     int       `json:"lives"`      // 0 - DefaultLives
+	Start     Point     `json:"start"`      // Клетка появления игрока
+	Goal      Zone      `json:"goal"`       // Дойдя сюда, игрок побеждает
+	Lanes     []LaneDef `json:"lanes"`
+	// Плотность движения по ходу уровня; пусто - к концу вдвое гуще
+	Density []DensityPoint `json:"density,omitempty"`
+	// Карта клеток строками символов tileSymbols; пусто - строится по полосам
+	Tiles []string `json:"tiles,omitempty"`
+}
+
+// ParseLevel разбирает и проверяет уровень. Неизвестные поля - ошибка,
+// чтобы опечатка в файле не превращалась молча в значение по умолчанию
+func ParseLevel(data []byte) (*Level, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var l Level
+	if err := dec.Decode(&l); err != nil {
+		return nil, err
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Hash - отпечаток описания уровня: у уровней, где хоть что-то отличается,
+// он разный. ID в отпечаток не входит, переименованный файл - тот же уровень
+func (l *Level) Hash() uint64 {
+	data, err := json.Marshal(l)
+	if err != nil {
+		panic(fmt.Sprintf("level %s: %v", l.ID, err)) // В Level только сериализуемые поля
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// Validate возвращает все найденные ошибки сразу
+func (l *Level) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if l.Name == "" {
+		add("name is empty")
+	}
+	if l.TimeLimit <= 0 {
+		add("time_limit must be positive, got %d", l.TimeLimit)
+	} else if l.TimeLimit > MaxTimeLimit {
+		add("time_limit %d is longer than %d seconds", l.TimeLimit, MaxTimeLimit)
+	}
+	if l.Lives < 0 {
+		add("lives must not be negative, got %d", l.Lives)
+	}
+	if l.Start.X < 0 || l.Start.X > ScreenWidth-GridSize || l.Start.Y < 0 || l.Start.Y > ScreenHeight-GridSize {
+		add("start (%g, %g) is outside the screen", l.Start.X, l.Start.Y)
+	} else if int(l.Start.X)%GridSize != 0 || int(l.Start.Y)%GridSize != 0 || l.Start.X != float64(int(l.Start.X)) || l.Start.Y != float64(int(l.Start.Y)) {
+		add("start (%g, %g) is not on the %dpx grid", l.Start.X, l.Start.Y, GridSize)
+	}
+	if l.Goal.Height <= 0 || l.Goal.Y < 0 || l.Goal.Y+l.Goal.Height > ScreenHeight {
+		add("goal (y %g, height %g) must be a non-empty zone inside the screen", l.Goal.Y, l.Goal.Height)
+	}
+
+	if len(l.Tiles) > 0 {
+		l.validateTiles(add)
+	}
+
+	for i, p := range l.Density {
+		if p.At < 0 || p.At > 1 {
+			add("density point %d: at %g must be within 0..1", i, p.At)
+		}
+		if i > 0 && p.At <= l.Density[i-1].At {
+			add("density point %d: at %g must be after the previous point", i, p.At)
+		}
+		if p.Rate <= 0 {
+			add("density point %d: rate must be positive, got %g", i, p.Rate)
+		}
+	}
+
+	for i, lane := range l.Lanes {
+		add := func(format string, args ...any) {
+			add("lane %d: "+format, append([]any{i}, args...)...)
+		}
+		if lane.Y < 0 || lane.Y > ScreenHeight-GridSize {
+			add("y %g is outside the screen", lane.Y)
+		}
+		switch lane.Kind {
+		case "", LaneRoad, LaneWater:
+		default:
+			add("kind %q must be %q or %q", lane.Kind, LaneRoad, LaneWater)
+		}
+		switch lane.Direction {
+		case DirLeft, DirRight, DirRandom:
+		default:
+			add("direction %q must be %q, %q or %q", lane.Direction, DirLeft, DirRight, DirRandom)
+		}
+		if (lane.SpeedMin != 0 || lane.SpeedMax != 0) && (lane.SpeedMin <= 0 || lane.SpeedMin > lane.SpeedMax) {
+			add("speed range %g..%g must be positive and ordered", lane.SpeedMin, lane.SpeedMax)
+		}
+		// Промежуток должен вмещать самую длинную машину полосы
+		longest, longestName := 0, ""
+		if len(lane.VehicleTypes()) == 0 {
+			add("no vehicle types, set vehicle or vehicles")
+		}
+		for _, name := range lane.VehicleTypes() {
+			v, ok := Vehicles[name]
+			if !ok {
+				add("unknown vehicle %q", name)
+				continue
+			}
+			if water := lane.Kind == LaneWater; v.Floats != water {
+				if water {
+					add("%s cannot float on a water lane", name)
+				} else {
+					add("%s can only be used on a water lane", name)
+				}
+			}
+			if v.Width > longest {
+				longest, longestName = v.Width, name
+			}
+		}
+		if lane.Count < 0 {
+			add("count must not be negative, got %d", lane.Count)
+		}
+		if lane.GapMin > lane.GapMax {
+			add("gap range %g..%g is not ordered", lane.GapMin, lane.GapMax)
+		}
+		if longest > 0 && lane.GapMin*GridSize < float64(longest+MinVehicleGap) {
+			add("gap_min %g leaves less than %dpx between a %s and the next vehicle", lane.GapMin, MinVehicleGap, longestName)
+		}
+		// Машины соседних полос не должны наезжать друг на друга
+		for j, other := range l.Lanes[:i] {
+			if math.Abs(lane.Y-other.Y) < GridSize {
+				add("y %g overlaps lane %d at y %g, lanes must be at least %dpx apart", lane.Y, j, other.Y, GridSize)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LoadLevels читает все *.json из fsys. Ключ - имя файла без расширения
+func LoadLevels(fsys fs.FS) (map[string]*Level, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make(map[string]*Level)
+	var errs []error
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l, err := ParseLevel(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("level %s: %w", name, indent(err)))
+			continue
+		}
+		l.ID = strings.TrimSuffix(path.Base(name), ".json")
+		levels[l.ID] = l
+	}
+	return levels, errors.Join(errs...)
+}
+
+// Ошибки валидации идут по одной на строку под именем файла
+func indent(err error) error {
+	msg := err.Error()
+	if !strings.Contains(msg, "\n") {
+		return err
+	}
+	return errors.New("\n  " + strings.ReplaceAll(msg, "\n", "\n  "))
+}
@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+// validLevel - уровень, который проходит проверку; тесты портят в нём по одному полю
+func validLevel() *Level {
+	return testLevel(
+		LaneDef{Y: 416, Direction: DirLeft, Vehicle: "car", Count: 2, GapMin: 4, GapMax: 6},
+		LaneDef{Y: 352, Kind: LaneWater, Direction: DirRight, Vehicle: "log", Count: 2, GapMin: 5, GapMax: 7},
+	)
+}
+
+// tileRows записывает карту строками символов, как в поле tiles
+func tileRows(m TileMap) []string {
+	rows := make([]string, GridHeight)
+	for r := range m {
+		var b strings.Builder
+		for _, t := range m[r] {
+			b.WriteByte(tileSymbols[t])
+		}
+		rows[r] = b.String()
+	}
+	return rows
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(l *Level)
+		want []string // Подстроки, которые должны быть в ошибке
+	}{
+		{"valid", func(*Level) {}, nil},
+		{"valid tiles", func(l *Level) { l.Tiles = tileRows(l.generateTiles()) }, nil},
+		{"empty name", func(l *Level) { l.Name = "" }, []string{"name is empty"}},
+		{"no time", func(l *Level) { l.TimeLimit = 0 }, []string{"time_limit must be positive"}},
+		{"too much time", func(l *Level) { l.TimeLimit = MaxTimeLimit + 1 }, []string{"time_limit 601 is longer than 600 seconds"}},
+		{"negative lives", func(l *Level) { l.Lives = -1 }, []string{"lives must not be negative"}},
+		{"start outside", func(l *Level) { l.Start.Y = ScreenHeight }, []string{"is outside the screen"}},
+		{"start off grid", func(l *Level) { l.Start.X = 100 }, []string{"is not on the 32px grid"}},
+		{"empty goal", func(l *Level) { l.Goal.Height = 0 }, []string{"goal (y 0, height 0)"}},
+		{"density order", func(l *Level) { l.Density = []DensityPoint{{At: 0.5, Rate: 1}, {At: 0.2, Rate: 1}} }, []string{"density point 1: at 0.2 must be after"}},
+		{"density rate", func(l *Level) { l.Density = []DensityPoint{{At: 0, Rate: 0}} }, []string{"density point 0: rate must be positive"}},
+		{"lane outside", func(l *Level) { l.Lanes[0].Y = -10 }, []string{"lane 0: y -10 is outside the screen"}},
+		{"lane kind", func(l *Level) { l.Lanes[0].Kind = "lava" }, []string{`lane 0: kind "lava"`}},
+		{"direction", func(l *Level) { l.Lanes[0].Direction = "up" }, []string{`lane 0: direction "up"`}},
+		{"speed order", func(l *Level) { l.Lanes[0].SpeedMin, l.Lanes[0].SpeedMax = 3, 2 }, []string{"lane 0: speed range 3..2"}},
+		{"no vehicles", func(l *Level) { l.Lanes[0].Vehicle = "" }, []string{"lane 0: no vehicle types"}},
+		{"unknown vehicle", func(l *Level) { l.Lanes[0].Vehicle = "tank" }, []string{`lane 0: unknown vehicle "tank"`}},
+		{"log on road", func(l *Level) { l.Lanes[0].Vehicles = []string{"log"} }, []string{"lane 0: log can only be used on a water lane"}},
+		{"car on water", func(l *Level) { l.Lanes[1].Vehicle = "car" }, []string{"lane 1: car cannot float on a water lane"}},
+		{"gap order", func(l *Level) { l.Lanes[0].GapMax = 3 }, []string{"lane 0: gap range 4..3 is not ordered"}},
+		{"overlapping lanes", func(l *Level) { l.Lanes[1].Y = 400 }, []string{"lane 1: y 400 overlaps lane 0 at y 416"}},
+		{"gap too small", func(l *Level) { l.Lanes[0].Vehicle, l.Lanes[0].GapMin = "truck", 3 }, []string{"lane 0: gap_min 3 leaves less than 32px between a truck"}},
+		{"tile rows", func(l *Level) { l.Tiles = []string{"...."} }, []string{"tiles must have 15 rows, got 1"}},
+		{"tile symbol", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[0] = "?" + l.Tiles[0][1:]
+		}, []string{`tiles row 0: unknown tile '?'`}},
+		{"lane on grass", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[13] = strings.Repeat(".", GridWidth)
+		}, []string{"lane 0: tiles row 13 has grass where the lane needs road"}},
+		{"water without a lane", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[5] = strings.Repeat("~", GridWidth)
+		}, []string{"tiles row 5 has water, but no water lane crosses it"}},
+		{"road without a lane", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[5] = "....#..............."
+		}, []string{"tiles row 5 has road, but no road lane crosses it"}},
+		{"goal outside the goal zone", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[3] = strings.Repeat("*", GridWidth)
+		}, []string{"tiles row 3 has goal outside the goal zone"}},
+		{"goal zone without goal tiles", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Tiles[0] = strings.Repeat(".", GridWidth)
+		}, []string{"tiles row 0 is in the goal zone, but not all of it is goal"}},
+		{"start in water", func(l *Level) {
+			l.Tiles = tileRows(l.generateTiles())
+			l.Start.Y = 352
+		}, []string{"start is on a water tile"}},
+		{"all errors at once", func(l *Level) {
+			l.Name = ""
+			l.Lanes[0].Direction = "up"
+			l.Lanes[1].Vehicle = "tank"
+		}, []string{"name is empty", `lane 0: direction "up"`, `lane 1: unknown vehicle "tank"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := validLevel()
+			tt.edit(l)
+			err := l.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("no error, want %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// Карта не может сделать реку из строки без речной полосы: по воде без полосы
+// можно было бы пройти, как по траве
+func TestParseLevelWaterWithoutLanes(t *testing.T) {
+	rows := []string{strings.Repeat("*", GridWidth)}
+	for len(rows) < GridHeight-1 {
+		rows = append(rows, strings.Repeat("~", GridWidth))
+	}
+	rows = append(rows, strings.Repeat("=", GridWidth))
+	data := `{"name": "x", "time_limit": 10, "goal": {"height": 5}, "start": {"x": 320, "y": 448}, "lanes": [], "tiles": ["` + strings.Join(rows, `", "`) + `"]}`
+	_, err := ParseLevel([]byte(data))
+	if err == nil || !strings.Contains(err.Error(), "tiles row 1 has water, but no water lane crosses it") {
+		t.Errorf("error = %v, want water without a lane", err)
+	}
+}
+
+func TestParseLevelUnknownField(t *testing.T) {
+	_, err := ParseLevel([]byte(`{"name": "x", "time_limit": 10, "goal": {"height": 5}, "start": {"x": 320, "y": 448}, "lifes": 3}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "lifes"`) {
+		t.Errorf("error = %v, want unknown field", err)
+	}
+}
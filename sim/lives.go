@@ -0,0 +1,39 @@
+package sim
+
+const (
+	DefaultLives      = 3            // Если в уровне не указано
+	InvulnerableTicks = TickRate * 2 // Неуязвимость после появления
+)
+
+// Игрок потерял жизнь: появляется на старте или последней разделительной
+// полосе и ненадолго становится неуязвимым.
+// Возвращает false, если жизней не осталось
+func (w *World) loseLife() bool {
+	w.Lives--
+	if w.Lives <= 0 {
+		return false
+	}
+	w.events = append(w.events, w.event(EventLifeLost))
+	w.clearNearMisses()
+	w.Player.X, w.Player.Y = w.checkpoint.X, w.checkpoint.Y
+	w.hopState = hopState{prev: w.hopState.prev}
+	w.invulnerable = InvulnerableTicks
+	return true
+}
+
+// Invulnerable сообщает, сколько ещё шагов игрок неуязвим
+func (w *World) Invulnerable() int {
+	return w.invulnerable
+}
+
+// Стоя на разделительной полосе, игрок запоминает её клетку
+func (w *World) updateCheckpoint() {
+	if w.hopState.active {
+		return
+	}
+	cx, cy := w.playerCenter()
+	if w.Map.At(cx, cy) == TileMedian {
+		col, row := int(cx)/GridSize, int(cy)/GridSize
+		w.checkpoint = Point{X: float64(col * GridSize), Y: float64(row * GridSize)}
+	}
+}
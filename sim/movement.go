@@ -0,0 +1,205 @@
+package sim
+
+import "fmt"
+
+type MovementMode int
+
+const (
+	MoveFree MovementMode = iota // Плавное движение со скоростью PlayerSpeed
+	MoveHop                      // Прыжок на одну клетку за нажатие
+)
+
+func (m MovementMode) String() string {
+	switch m {
+	case MoveFree:
+		return "Free"
+	case MoveHop:
+		return "Hop"
+	default:
+		return fmt.Sprintf("MovementMode(%d)", int(m))
+	}
+}
+
+// В файлах настроек режим хранится словом, а не числом
+var movementModeNames = map[MovementMode]string{MoveFree: "free", MoveHop: "hop"}
+
+func (m MovementMode) MarshalText() ([]byte, error) {
+	name, ok := movementModeNames[m]
+	if !ok {
+		return nil, fmt.Errorf("unknown movement mode %d", int(m))
+	}
+	return []byte(name), nil
+}
+
+func (m *MovementMode) UnmarshalText(text []byte) error {
+	for mode, name := range movementModeNames {
+		if name == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown movement mode %q", text)
+}
+
+// Длительность одного прыжка в шагах симуляции
+const HopTicks = 8
+
+// Facing - куда смотрит игрок, для анимации
+type Facing int
+
+const (
+	FaceUp Facing = iota
+	FaceDown
+	FaceLeft
+	FaceRight
+)
+
+type direction int
+
+const (
+	dirNone direction = iota
+	dirUp
+	dirDown
+	dirLeft
+	dirRight
+)
+
+// hopState - прыжок в процессе и нажатие, отложенное до его конца
+type hopState struct {
+	active       bool
+	tick         int
+	fromX, fromY float64
+	toX, toY     float64
+	buffered     direction
+	prev         Input
+}
+
+// Нажатие, появившееся на этом шаге; при нескольких сразу вперёд важнее
+func pressed(in, prev Input) direction {
+	switch {
+	case in.Up && !prev.Up:
+		return dirUp
+	case in.Down && !prev.Down:
+		return dirDown
+	case in.Left && !prev.Left:
+		return dirLeft
+	case in.Right && !prev.Right:
+		return dirRight
+	}
+	return dirNone
+}
+
+func (w *World) movePlayer(dt float64, in Input) {
+	if w.Mode == MoveHop {
+		w.hop(in)
+		w.Walking = w.hopState.active
+		return
+	}
+
+	x0, y0 := w.Player.X, w.Player.Y
+	switch {
+	case in.Up:
+		w.Facing = FaceUp
+	case in.Down:
+		w.Facing = FaceDown
+	case in.Left:
+		w.Facing = FaceLeft
+	case in.Right:
+		w.Facing = FaceRight
+	}
+
+	if in.Left {
+		w.Player.X -= float64(GridSize) * dt * PlayerSpeed
+	}
+	if in.Right {
+		w.Player.X += float64(GridSize) * dt * PlayerSpeed
+	}
+	if in.Up {
+		w.Player.Y -= float64(GridSize) * dt * PlayerSpeed
+	}
+	if in.Down {
+		w.Player.Y += float64(GridSize) * dt * PlayerSpeed
+	}
+
+	// Ограничение движения игрока
+	w.Player.X = clamp(w.Player.X, 0, ScreenWidth-float64(GridSize))
+	w.Player.Y = clamp(w.Player.Y, TextAreaHeight, ScreenHeight-float64(GridSize))
+	w.Walking = w.Player.X != x0 || w.Player.Y != y0
+}
+
+func (w *World) hop(in Input) {
+	h := &w.hopState
+	if dir := pressed(in, h.prev); dir != dirNone {
+		h.buffered = dir
+	}
+	h.prev = in
+
+	if h.active {
+		h.tick++
+		t := float64(h.tick) / HopTicks
+		t = t * (2 - t) // Замедление к приземлению
+		w.Player.X = h.fromX + (h.toX-h.fromX)*t
+		w.Player.Y = h.fromY + (h.toY-h.fromY)*t
+		if h.tick < HopTicks {
+			return
+		}
+		// На шаге приземления игрок стоит на клетке, и его видят проверки
+		// воды и разделительной полосы. Следующий прыжок - со следующего шага
+		h.active = false
+		w.Player.X, w.Player.Y = h.toX, h.toY
+		return
+	}
+
+	if h.buffered == dirNone {
+		return
+	}
+	dx, dy := 0.0, 0.0
+	switch h.buffered {
+	case dirUp:
+		dy = -GridSize
+		w.Facing = FaceUp
+	case dirDown:
+		dy = GridSize
+		w.Facing = FaceDown
+	case dirLeft:
+		dx = -GridSize
+		w.Facing = FaceLeft
+	case dirRight:
+		dx = GridSize
+		w.Facing = FaceRight
+	}
+	h.buffered = dirNone
+
+	// Те же границы, что и при плавном движении: у края прыжок короче или его нет
+	toX := clamp(w.Player.X+dx, 0, ScreenWidth-float64(GridSize))
+	toY := clamp(w.Player.Y+dy, TextAreaHeight, ScreenHeight-float64(GridSize))
+	if toX == w.Player.X && toY == w.Player.Y {
+		return
+	}
+	*h = hopState{
+		active: true,
+		fromX:  w.Player.X,
+		fromY:  w.Player.Y,
+		toX:    toX,
+		toY:    toY,
+		prev:   h.prev,
+	}
+}
+
+// Форма игрока для столкновений. В прыжке игрок уже занимает
+// клетку, куда приземлится, поэтому проскочить сквозь машину нельзя
+func (w *World) playerCollider() Collider {
+	if !w.hopState.active {
+		return w.Player.Collider()
+	}
+	return w.Player.colliderAt(w.hopState.toX, w.hopState.toY)
+}
+
+// Игрок в зоне цели - победа. В режиме прыжков считается только приземление
+func (w *World) reachedGoal() bool {
+	if w.Mode == MoveHop && w.hopState.active {
+		return false
+	}
+	goal := w.Level.Goal
+	return w.Player.Y <= goal.Y+goal.Height && w.Player.Y+float64(w.Player.Height) >= goal.Y
+}
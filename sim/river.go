@@ -0,0 +1,47 @@
+package sim
+
+// Центр игрока: по нему решается, в какой он полосе и стоит ли на плоту
+func (w *World) playerCenter() (float64, float64) {
+	return w.Player.X + float64(w.Player.Width)/2, w.Player.Y + float64(w.Player.Height)/2
+}
+
+// Речная полоса под игроком или nil
+func (w *World) waterUnder() *Lane {
+	_, cy := w.playerCenter()
+	for _, lane := range w.Lanes {
+		if lane.Water && cy >= lane.Y && cy < lane.Y+GridSize {
+			return lane
+		}
+	}
+	return nil
+}
+
+// Плот, на котором стоит игрок. В прыжке игрок ни на чём не стоит
+func (w *World) platformUnder() *GameObject {
+	if w.hopState.active {
+		return nil
+	}
+	lane := w.waterUnder()
+	if lane == nil {
+		return nil
+	}
+	cx, _ := w.playerCenter()
+	for _, p := range lane.Cars {
+		r := p.Collider().Bounds()
+		if !p.Submerged && cx >= r.X0 && cx < r.X1 {
+			return p
+		}
+	}
+	return nil
+}
+
+// Игрок в реке не на плоту или унесён плотом за край экрана
+func (w *World) drowned() bool {
+	if w.hopState.active || w.waterUnder() == nil {
+		return false
+	}
+	if w.Player.X < 0 || w.Player.X > ScreenWidth-GridSize {
+		return true
+	}
+	return w.platformUnder() == nil
+}
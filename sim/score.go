@@ -0,0 +1,62 @@
+package sim
+
+// Очки за разные достижения до умножения на множитель
+const (
+	PointsPerLane    = 10 // За каждую пересечённую полосу, один раз за забег
+	PointsPerSecond  = 5  // За каждую оставшуюся секунду при победе
+	PointsNearMiss   = 25 // За машину, проехавшую рядом и не задевшую
+	NearMissDistance = GridSize / 2
+	MaxMultiplier    = 5
+)
+
+// Score - счёт текущего забега
+type Score struct {
+	Points     int
+	Multiplier int // Растёт с каждым успешным переходом подряд
+}
+
+// MultiplierFor возвращает множитель после streak успешных переходов подряд
+func MultiplierFor(streak int) int {
+	return min(1+max(streak, 0), MaxMultiplier)
+}
+
+// award начисляет очки с учётом множителя и добавляет событие
+func (w *World) award(kind EventKind, points int) {
+	if points <= 0 {
+		return
+	}
+	points *= w.Score.Multiplier
+	w.Score.Points += points
+	ev := w.event(kind)
+	ev.Points = points
+	w.events = append(w.events, ev)
+}
+
+// Очки за продвижение: полоса засчитывается, когда игрок целиком оказался выше неё
+func (w *World) scoreProgress() {
+	for i, lane := range w.Level.Lanes {
+		if !w.crossed[i] && w.Player.Y+float64(w.Player.Height) <= lane.Y {
+			w.crossed[i] = true
+			w.award(EventLaneCrossed, PointsPerLane)
+		}
+	}
+}
+
+// Машина рядом с игроком, но без столкновения, отмечается. Опасное сближение
+// засчитывается, когда отмеченная машина отъехала, так и не задев игрока
+func (w *World) checkNearMiss(car *GameObject) {
+	car.nearTick = w.Tick
+	if w.playerCollider().Bounds().Inset(-NearMissDistance).Overlaps(car.Collider().Bounds()) {
+		car.nearMiss = true
+	} else {
+		w.passNearMiss(car)
+	}
+}
+
+// passNearMiss снимает отметку с отъехавшей машины и начисляет за неё очки
+func (w *World) passNearMiss(car *GameObject) {
+	if car.nearMiss {
+		car.nearMiss = false
+		w.award(EventNearMiss, PointsNearMiss)
+	}
+}
@@ -0,0 +1,180 @@
+package sim
+
+import (
+	"image"
+	"math"
+)
+
+// Rect - прямоугольник в дробных пикселях мира. Края не входят:
+// прямоугольники, которые только касаются, не пересекаются
+type Rect struct {
+	X0, Y0, X1, Y1 float64
+}
+
+func (r Rect) Empty() bool {
+	return r.X0 >= r.X1 || r.Y0 >= r.Y1
+}
+
+func (r Rect) Overlaps(o Rect) bool {
+	return r.X0 < o.X1 && o.X0 < r.X1 && r.Y0 < o.Y1 && o.Y0 < r.Y1
+}
+
+func (r Rect) Add(x, y float64) Rect {
+	return Rect{r.X0 + x, r.Y0 + y, r.X1 + x, r.Y1 + y}
+}
+
+// Inset сжимает прямоугольник на d с каждой стороны; отрицательное d расширяет
+func (r Rect) Inset(d float64) Rect {
+	return Rect{r.X0 + d, r.Y0 + d, r.X1 - d, r.Y1 - d}
+}
+
+func (r Rect) Intersect(o Rect) Rect {
+	return Rect{max(r.X0, o.X0), max(r.Y0, o.Y0), min(r.X1, o.X1), min(r.Y1, o.Y1)}
+}
+
+// PlayerShape - круг чуть меньше клетки: задеть машину углом клетки нельзя
+var PlayerShape = Circle(GridSize/2, GridSize/2, GridSize*3/8)
+
+type ShapeKind int
+
+const (
+	ShapeRect   ShapeKind = iota // Прямоугольник Bounds
+	ShapeCircle                  // Круг, вписанный в квадрат Bounds
+	ShapeMask                    // Непрозрачные пиксели Mask, растянутой на Bounds
+)
+
+// Shape - форма для столкновений относительно левого верхнего угла объекта.
+// Нулевая форма - весь объект
+type Shape struct {
+	Kind   ShapeKind
+	Bounds Rect
+	Mask   *Mask
+}
+
+// InsetRect - прямоугольник объекта w x h, сжатый по краям
+func InsetRect(w, h int, left, top, right, bottom float64) Shape {
+	return Shape{Kind: ShapeRect, Bounds: Rect{left, top, float64(w) - right, float64(h) - bottom}}
+}
+
+func Circle(cx, cy, r float64) Shape {
+	return Shape{Kind: ShapeCircle, Bounds: Rect{cx - r, cy - r, cx + r, cy + r}}
+}
+
+// MaskShape - маска на весь объект, один пиксель маски - один пиксель мира
+func MaskShape(m *Mask) Shape {
+	return Shape{Kind: ShapeMask, Bounds: Rect{0, 0, float64(m.W), float64(m.H)}, Mask: m}
+}
+
+// Mask - какие пиксели объекта твёрдые
+type Mask struct {
+	W, H  int
+	solid []bool
+}
+
+// Пиксель считается твёрдым, если он непрозрачнее половины
+const maskAlphaThreshold = 0x8000
+
+// NewAlphaMask строит маску w x h по прозрачности img, растягивая картинку
+// на размер объекта, как при отрисовке спрайта
+func NewAlphaMask(img image.Image, w, h int) *Mask {
+	m := &Mask{W: w, H: h, solid: make([]bool, w*h)}
+	b := img.Bounds()
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + (2*y+1)*b.Dy()/(2*h)
+		for x := 0; x < w; x++ {
+			sx := b.Min.X + (2*x+1)*b.Dx()/(2*w)
+			_, _, _, a := img.At(sx, sy).RGBA()
+			m.solid[y*w+x] = a >= maskAlphaThreshold
+		}
+	}
+	return m
+}
+
+// Solid сообщает, твёрдый ли пиксель (x, y); за пределами маски - нет
+func (m *Mask) Solid(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.W && y < m.H && m.solid[y*m.W+x]
+}
+
+// Collider - форма, поставленная в точку мира
+type Collider struct {
+	Shape
+	X, Y float64
+}
+
+// Bounds - границы формы в мире
+func (c Collider) Bounds() Rect {
+	return c.Shape.Bounds.Add(c.X, c.Y)
+}
+
+// Overlaps проверяет пересечение двух форм
+func (c Collider) Overlaps(o Collider) bool {
+	if !c.Bounds().Overlaps(o.Bounds()) {
+		return false
+	}
+	switch {
+	case c.Kind == ShapeMask:
+		return c.maskOverlaps(o)
+	case o.Kind == ShapeMask:
+		return o.maskOverlaps(c)
+	case c.Kind == ShapeCircle && o.Kind == ShapeCircle:
+		b, ob := c.Bounds(), o.Bounds()
+		r, or := (b.X1-b.X0)/2, (ob.X1-ob.X0)/2
+		dx, dy := (b.X0+r)-(ob.X0+or), (b.Y0+r)-(ob.Y0+or)
+		return dx*dx+dy*dy < (r+or)*(r+or)
+	case c.Kind == ShapeCircle:
+		return c.overlapsRect(o.Bounds())
+	default:
+		return o.overlapsRect(c.Bounds())
+	}
+}
+
+// overlapsRect проверяет пересечение формы с прямоугольником мира
+func (c Collider) overlapsRect(r Rect) bool {
+	b := c.Bounds()
+	if !b.Overlaps(r) {
+		return false
+	}
+	switch c.Kind {
+	case ShapeCircle:
+		rad := (b.X1 - b.X0) / 2
+		cx, cy := b.X0+rad, b.Y0+rad
+		dx := cx - max(r.X0, min(cx, r.X1))
+		dy := cy - max(r.Y0, min(cy, r.Y1))
+		return dx*dx+dy*dy < rad*rad
+	case ShapeMask:
+		return c.anySolid(r, func(Rect) bool { return true })
+	default:
+		return true
+	}
+}
+
+// maskOverlaps ищет твёрдый пиксель маски, который пересекает форму o
+func (c Collider) maskOverlaps(o Collider) bool {
+	return c.anySolid(o.Bounds(), o.overlapsRect)
+}
+
+// anySolid перебирает твёрдые пиксели маски внутри r и проверяет каждый функцией hit
+func (c Collider) anySolid(r Rect, hit func(Rect) bool) bool {
+	b := c.Bounds()
+	area := b.Intersect(r)
+	if area.Empty() {
+		return false
+	}
+	// Размер пикселя маски в мире
+	sx := (b.X1 - b.X0) / float64(c.Mask.W)
+	sy := (b.Y1 - b.Y0) / float64(c.Mask.H)
+	x0, x1 := int(math.Floor((area.X0-b.X0)/sx)), int(math.Ceil((area.X1-b.X0)/sx))
+	y0, y1 := int(math.Floor((area.Y0-b.Y0)/sy)), int(math.Ceil((area.Y1-b.Y0)/sy))
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if !c.Mask.Solid(x, y) {
+				continue
+			}
+			px := Rect{b.X0 + float64(x)*sx, b.Y0 + float64(y)*sy, b.X0 + float64(x+1)*sx, b.Y0 + float64(y+1)*sy}
+			if px.Overlaps(r) && hit(px) {
+				return true
+			}
+		}
+	}
+	return false
+}
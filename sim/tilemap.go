@@ -0,0 +1,195 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tile - клетка карты уровня
+type Tile byte
+
+const (
+	TileGrass    Tile = iota
+	TileSidewalk      // Тротуар у старта
+	TileRoad
+	TileMedian // Разделительная полоса: безопасна и служит точкой возрождения
+	TileGoal
+	TileWater
+	TileCount
+)
+
+// Символы клеток в поле tiles файла уровня
+const tileSymbols = ".=#-*~"
+
+func (t Tile) String() string {
+	switch t {
+	case TileGrass:
+		return "grass"
+	case TileSidewalk:
+		return "sidewalk"
+	case TileRoad:
+		return "road"
+	case TileMedian:
+		return "median"
+	case TileGoal:
+		return "goal"
+	case TileWater:
+		return "water"
+	default:
+		return fmt.Sprintf("Tile(%d)", int(t))
+	}
+}
+
+// Safe сообщает, можно ли стоять на клетке без опасности: по безопасным
+// клеткам не проходит ни одна полоса, машинам и реке там не место
+func (t Tile) Safe() bool {
+	return t != TileRoad && t != TileWater
+}
+
+// TileMap - карта уровня на сетке GridSize, строки сверху вниз
+type TileMap [GridHeight][GridWidth]Tile
+
+// At возвращает клетку, в которую попадает точка (x, y); за краем - трава
+func (m *TileMap) At(x, y float64) Tile {
+	col, row := int(x)/GridSize, int(y)/GridSize
+	if x < 0 || y < 0 || col >= GridWidth || row >= GridHeight {
+		return TileGrass
+	}
+	return m[row][col]
+}
+
+// Строки сетки, которые задевает полоса
+func laneRows(lane LaneDef) (int, int) {
+	return int(lane.Y) / GridSize, (int(lane.Y) + GridSize - 1) / GridSize
+}
+
+// TileMap возвращает карту уровня: из поля tiles или построенную по полосам
+func (l *Level) TileMap() TileMap {
+	if len(l.Tiles) > 0 {
+		m, _ := parseTiles(l.Tiles)
+		return m
+	}
+	return l.generateTiles()
+}
+
+func parseTiles(rows []string) (TileMap, error) {
+	var m TileMap
+	if len(rows) != GridHeight {
+		return m, fmt.Errorf("tiles must have %d rows, got %d", GridHeight, len(rows))
+	}
+	for r, row := range rows {
+		if len(row) != GridWidth {
+			return m, fmt.Errorf("tiles row %d must have %d cells, got %d", r, GridWidth, len(row))
+		}
+		for c := 0; c < GridWidth; c++ {
+			t := strings.IndexByte(tileSymbols, row[c])
+			if t < 0 {
+				return m, fmt.Errorf("tiles row %d: unknown tile %q, expected one of %q", r, row[c], tileSymbols)
+			}
+			m[r][c] = Tile(t)
+		}
+	}
+	return m, nil
+}
+
+// Карта по полосам для уровней без tiles: под полосами дорога или вода,
+// свободные строки между полосами - разделительные, у старта тротуар
+func (l *Level) generateTiles() TileMap {
+	var kind [GridHeight]Tile
+	var used [GridHeight]bool
+	top, bottom := GridHeight, -1
+	for _, lane := range l.Lanes {
+		t := TileRoad
+		if lane.Kind == LaneWater {
+			t = TileWater
+		}
+		from, to := laneRows(lane)
+		for r := max(from, 0); r <= min(to, GridHeight-1); r++ {
+			kind[r], used[r] = t, true
+		}
+		top, bottom = min(top, from), max(bottom, to)
+	}
+
+	startRow := int(l.Start.Y) / GridSize
+	for r := range kind {
+		if used[r] {
+			continue
+		}
+		y := float64(r * GridSize)
+		switch {
+		case r == startRow:
+			kind[r] = TileSidewalk
+		case y < l.Goal.Y+l.Goal.Height && y+GridSize > l.Goal.Y:
+			kind[r] = TileGoal
+		case r > top && r < bottom:
+			kind[r] = TileMedian
+		default:
+			kind[r] = TileGrass
+		}
+	}
+
+	var m TileMap
+	for r := range m {
+		for c := range m[r] {
+			m[r][c] = kind[r]
+		}
+	}
+	return m
+}
+
+// Ошибки карты. Правила игры берутся из полос и зоны цели, а карта только
+// показывает их, поэтому они должны совпадать: полосы лежат на дороге или воде,
+// дорога и вода - только под полосами, финиш - ровно в зоне цели, старт -
+// на безопасной клетке
+func (l *Level) validateTiles(add func(format string, args ...any)) {
+	m, err := parseTiles(l.Tiles)
+	if err != nil {
+		add("%v", err)
+		return
+	}
+	var laneKind [GridHeight][TileCount]bool // Полосы какого вида пересекают строку
+	for _, lane := range l.Lanes {
+		t := TileRoad
+		if lane.Kind == LaneWater {
+			t = TileWater
+		}
+		from, to := laneRows(lane)
+		for r := max(from, 0); r <= min(to, GridHeight-1); r++ {
+			laneKind[r][t] = true
+		}
+	}
+	for r := range m {
+		y := float64(r * GridSize)
+		inGoal := y < l.Goal.Y+l.Goal.Height && y+GridSize > l.Goal.Y
+		for _, t := range []Tile{TileRoad, TileWater} {
+			if !laneKind[r][t] && slices.Contains(m[r][:], t) {
+				add("tiles row %d has %s, but no %s lane crosses it", r, t, t)
+			}
+		}
+		switch {
+		case inGoal && slices.ContainsFunc(m[r][:], func(t Tile) bool { return t != TileGoal }):
+			add("tiles row %d is in the goal zone, but not all of it is goal", r)
+		case !inGoal && slices.Contains(m[r][:], TileGoal):
+			add("tiles row %d has goal outside the goal zone (y %g, height %g)", r, l.Goal.Y, l.Goal.Height)
+		}
+	}
+	for i, lane := range l.Lanes {
+		want := TileRoad
+		if lane.Kind == LaneWater {
+			want = TileWater
+		}
+		from, to := laneRows(lane)
+		for r := max(from, 0); r <= min(to, GridHeight-1); r++ {
+			for c := 0; c < GridWidth; c++ {
+				if m[r][c] != want {
+					add("lane %d: tiles row %d has %s where the lane needs %s", i, r, m[r][c], want)
+					break
+				}
+			}
+		}
+	}
+	if t := m.At(l.Start.X, l.Start.Y); !t.Safe() {
+		add("start is on a %s tile", t)
+	}
+}
@@ -0,0 +1,79 @@
+package sim
+
+import (
+	"fmt"
+	"image/png"
+
+	sprites "run-boy-run/image"
+)
+
+// Цикл погружения черепах: большую часть времени на плаву, затем под водой
+const (
+	SinkCycleTicks = TickRate * 4
+	SinkDownTicks  = TickRate
+)
+
+// VehicleType - вид транспорта из каталога. Плавучие типы (брёвна, черепахи)
+// ездят по реке, и на них можно стоять
+type VehicleType struct {
+	Width, Height      int
+	SpeedMin, SpeedMax float64 // Клеток в секунду, если полоса не задаёт скорость сама
+	Shape              Shape   // Часть спрайта, опасная для игрока или годная, чтобы стоять
+	Floats             bool
+	Sinks              bool
+}
+
+// Vehicles - каталог транспорта, ключ - имя из файла уровня
+var Vehicles = map[string]VehicleType{
+	"motorcycle": {
+		Width: GridSize, Height: GridSize,
+		SpeedMin: 4, SpeedMax: 5.5,
+		Shape: spriteMask("motorcycle.png", GridSize, GridSize),
+	},
+	"car": {
+		Width: GridSize * 3 / 2, Height: GridSize,
+		SpeedMin: 2.5, SpeedMax: 4,
+		Shape: spriteMask("car.png", GridSize*3/2, GridSize),
+	},
+	"bus": {
+		Width: GridSize * 2, Height: GridSize,
+		SpeedMin: 2, SpeedMax: 3,
+		// Спрайт автобуса непрозрачный, маску по нему не построить
+		Shape: InsetRect(GridSize*2, GridSize, 2, 3, 2, 3),
+	},
+	"truck": {
+		Width: GridSize * 3, Height: GridSize,
+		SpeedMin: 1.5, SpeedMax: 2.5,
+		Shape: spriteMask("truck.png", GridSize*3, GridSize),
+	},
+	"log": {
+		Width: GridSize * 3, Height: GridSize,
+		SpeedMin: 1.5, SpeedMax: 2.5,
+		Floats: true,
+	},
+	"log_long": {
+		Width: GridSize * 5, Height: GridSize,
+		SpeedMin: 1, SpeedMax: 2,
+		Floats: true,
+	},
+	"turtles": {
+		Width: GridSize * 3, Height: GridSize,
+		SpeedMin: 1.5, SpeedMax: 2.5,
+		Floats: true, Sinks: true,
+	},
+}
+
+// spriteMask - маска по прозрачности встроенного спрайта. Спрайты из -assets
+// на столкновения не влияют, иначе реплеи зависели бы от подменённых картинок
+func spriteMask(name string, w, h int) Shape {
+	f, err := sprites.FS("").Open(name)
+	if err != nil {
+		panic(fmt.Sprintf("collision mask: %v", err))
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		panic(fmt.Sprintf("collision mask %s: %v", name, err))
+	}
+	return MaskShape(NewAlphaMask(img, w, h))
+}
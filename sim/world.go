@@ -0,0 +1,271 @@
+// Package sim содержит правила игры без зависимости от Ebiten:
+// состояние мира, движение, столкновения и таймер.
+package sim
+
+import (
+	"iter"
+	"math/rand"
+)
+
+// Input - состояние управления на один шаг симуляции
+type Input struct {
+	Left, Right, Up, Down bool
+}
+
+type EventKind int
+
+const (
+	EventWin         EventKind = iota // Игрок дошёл до цели
+	EventHit                          // Игрок сбит или утонул, жизней не осталось
+	EventTimeUp                       // Время вышло
+	EventLaneCrossed                  // Очки за пересечённую полосу
+	EventNearMiss                     // Очки за опасное сближение
+	EventTimeBonus                    // Очки за оставшееся время
+	EventLifeLost                     // Игрок сбит или утонул и появился на старте
+)
+
+// Event - то, что произошло за шаг симуляции
+type Event struct {
+	Kind   EventKind
+	Points int     // Начисленные очки с учётом множителя
+	X, Y   float64 // Где был игрок
+}
+
+type Outcome int
+
+const (
+	Running Outcome = iota
+	Won
+	Lost
+)
+
+// RulesVersion увеличивается при каждом изменении правил, после которого
+// тот же Config с тем же вводом даёт другой забег. По нему реплеи,
+// записанные по старым правилам, отличаются от воспроизводимых
+const RulesVersion = 5
+
+// Config - всё, что определяет забег, кроме ввода
+type Config struct {
+	Difficulty int
+	Level      *Level
+	Seed       int64
+	Mode       MovementMode
+	Streak     int // Успешных переходов подряд до этого забега
+}
+
+type World struct {
+	Seed         int64
+	Tick         int // Номер текущего шага симуляции
+	Player       *GameObject
+	Facing       Facing // Куда смотрит игрок
+	Walking      bool   // Игрок сдвинулся на этом шаге
+	Map          TileMap
+	Lanes        []*Lane
+	Cars         []*GameObject // Машины всех дорог
+	Platforms    []*GameObject // Плоты всех рек
+	Difficulty   int
+	Level        *Level
+	Mode         MovementMode
+	LevelTime    int // Время для текущего уровня
+	CurrentTime  int
+	Outcome      Outcome
+	Score        Score
+	Lives        int
+	rng          *rand.Rand
+	hopState     hopState
+	checkpoint   Point         // Где игрок появится после потери жизни
+	invulnerable int           // Шагов неуязвимости осталось
+	crossed      []bool        // Полосы, за которые уже начислены очки
+	events       []Event       // События текущего шага
+	near         []*GameObject // Машины рядом с игроком при прошлой проверке
+	nearNext     []*GameObject
+	// Машины, которые надо проверить на столкновение с областью; тесты
+	// подменяют поиск по полосам перебором всех машин
+	broadPhase func(area Rect) iter.Seq[*GameObject]
+}
+
+// NewWorld создаёт мир по конфигурации. Один и тот же Config
+// при одинаковом вводе всегда даёт одну и ту же игру.
+func NewWorld(cfg Config) *World {
+	w := &World{
+		Seed:       cfg.Seed,
+		Difficulty: cfg.Difficulty,
+		Level:      cfg.Level,
+		Mode:       cfg.Mode,
+		LevelTime:  cfg.Level.TimeLimit,
+		Score:      Score{Multiplier: MultiplierFor(cfg.Streak)},
+		rng:        rand.New(rand.NewSource(cfg.Seed)),
+		crossed:    make([]bool, len(cfg.Level.Lanes)),
+		Lives:      cfg.Level.Lives,
+		Map:        cfg.Level.TileMap(),
+		checkpoint: cfg.Level.Start,
+	}
+	if w.Lives == 0 {
+		w.Lives = DefaultLives
+	}
+
+	w.broadPhase = w.nearbyCars
+	w.CurrentTime = w.LevelTime
+	w.initialize()
+	return w
+}
+
+func (w *World) initialize() {
+	// Настройка начального положения игрока
+	w.Player = &GameObject{
+		X:      w.Level.Start.X,
+		Y:      w.Level.Start.Y,
+		Speed:  PlayerSpeed,
+		Width:  GridSize,
+		Height: GridSize,
+		Shape:  PlayerShape,
+	}
+
+	// Полосы со своими машинами
+	w.Lanes = nil
+	for _, def := range w.Level.Lanes {
+		w.Lanes = append(w.Lanes, newLane(def, w.rng))
+	}
+	w.collectObjects()
+}
+
+// Машины и плоты полос в общих списках для столкновений и отрисовки
+func (w *World) collectObjects() {
+	w.Cars = w.Cars[:0]
+	w.Platforms = w.Platforms[:0]
+	for _, lane := range w.Lanes {
+		if lane.Water {
+			w.Platforms = append(w.Platforms, lane.Cars...)
+		} else {
+			w.Cars = append(w.Cars, lane.Cars...)
+		}
+	}
+}
+
+// Step продвигает мир на dt секунд и возвращает произошедшие события.
+// Для воспроизводимости dt должен быть равен TickDuration.
+// После победы или поражения мир больше не меняется.
+func (w *World) Step(dt float64, in Input) []Event {
+	if w.Outcome != Running {
+		return nil
+	}
+	w.Tick++
+	w.events = nil
+
+	// Управление игроком
+	w.movePlayer(dt, in)
+	w.scoreProgress()
+	w.updateCheckpoint()
+
+	// Проверка победы - дошёл до цели
+	if w.reachedGoal() {
+		w.award(EventTimeBonus, w.CurrentTime*PointsPerSecond)
+		return w.finish(Won, EventWin)
+	}
+
+	// Обновление автомобилей и плотов; игрок на плоту едет вместе с ним
+	raft := w.platformUnder()
+	density := w.density()
+	for _, lane := range w.Lanes {
+		lane.Update(dt, w.rng, density)
+	}
+	w.collectObjects()
+	if raft != nil {
+		w.Player.X += raft.Velocity() * dt
+	}
+
+	if w.invulnerable > 0 {
+		w.invulnerable--
+	} else if (w.checkCollisions() || w.drowned()) && !w.loseLife() {
+		return w.finish(Lost, EventHit)
+	}
+
+	// Обновление времени - считаем целые шаги, а не накопленные секунды
+	if w.Tick%TickRate == 0 {
+		w.CurrentTime -= 1
+
+		if w.CurrentTime <= 0 {
+			return w.finish(Lost, EventTimeUp)
+		}
+	}
+
+	return w.events
+}
+
+func (w *World) finish(outcome Outcome, kind EventKind) []Event {
+	w.Outcome = outcome
+	w.Walking = false
+	w.events = append(w.events, w.event(kind))
+	return w.events
+}
+
+// Событие в точке, где сейчас игрок
+func (w *World) event(kind EventKind) Event {
+	return Event{Kind: kind, X: w.Player.X, Y: w.Player.Y}
+}
+
+// Столкновение проверяется до опасных сближений, поэтому результат
+// не зависит от порядка машин: на шаге столкновения сближения не засчитываются
+func (w *World) checkCollisions() bool {
+	player := w.playerCollider()
+	area := player.Bounds().Inset(-NearMissDistance)
+	for car := range w.broadPhase(area) {
+		if player.Overlaps(car.Collider()) {
+			w.clearNearMisses()
+			return true
+		}
+	}
+
+	next := w.nearNext[:0]
+	for car := range w.broadPhase(area) {
+		w.checkNearMiss(car)
+		if car.nearMiss {
+			next = append(next, car)
+		}
+	}
+	// Машины, которые были рядом, но больше не попадают в область, уже отъехали
+	for _, car := range w.near {
+		if car.nearTick != w.Tick {
+			w.passNearMiss(car)
+		}
+	}
+	w.near, w.nearNext = next, w.near
+	return false
+}
+
+// nearbyCars перебирает машины, которые могут задеть область area: только
+// машины дорог, задевающих её по вертикали, и только те, что рядом по X.
+// Остальные заведомо далеко
+func (w *World) nearbyCars(area Rect) iter.Seq[*GameObject] {
+	return func(yield func(*GameObject) bool) {
+		for _, lane := range w.Lanes {
+			if lane.Water || !lane.Overlaps(area) {
+				continue
+			}
+			for _, car := range lane.Near(area.X0, area.X1) {
+				if !yield(car) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// После столкновения или падения в воду игрок появляется заново,
+// и машины, что были рядом, уже не засчитываются
+func (w *World) clearNearMisses() {
+	for _, car := range w.near {
+		car.nearMiss = false
+	}
+	w.near = w.near[:0]
+}
+
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,408 @@
+package sim
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testLevel - уровень с одной жизнью: старт внизу посередине, цель наверху
+func testLevel(lanes ...LaneDef) *Level {
+	return &Level{
+		Name:      "test",
+		TimeLimit: 30,
+		Lives:     1,
+		Start:     Point{X: 320, Y: 448},
+		Goal:      Zone{Y: 0, Height: 5},
+		Lanes:     lanes,
+	}
+}
+
+// emptyLane - полоса влево без машин при старте; новые машины появятся
+// не раньше чем через несколько тысяч шагов, машины ставит сам тест
+func emptyLane(y float64, kind, vehicle string) LaneDef {
+	return LaneDef{Kind: kind, Y: y, Direction: DirLeft, SpeedMin: 1, SpeedMax: 1, Vehicle: vehicle, GapMin: 100, GapMax: 100}
+}
+
+// place ставит машину на полосу lane. Машины полосы влево должны идти по возрастанию X
+func place(w *World, lane int, vehicle string, x float64) *GameObject {
+	l := w.Lanes[lane]
+	car := l.newCar(vehicle, x)
+	l.Cars = append(l.Cars, car)
+	w.collectObjects()
+	return car
+}
+
+// lastEvent - вид последнего события шага или -1, если событий не было
+func lastEvent(events []Event) EventKind {
+	if len(events) == 0 {
+		return -1
+	}
+	return events[len(events)-1].Kind
+}
+
+func TestCollisions(t *testing.T) {
+	road := emptyLane(448, LaneRoad, "bus")
+	water := emptyLane(448, LaneWater, "log")
+	tests := []struct {
+		name  string
+		lanes []LaneDef
+		setup func(w *World)
+		want  Outcome
+	}{
+		{"car on player", []LaneDef{road}, func(w *World) { place(w, 0, "bus", 300) }, Lost},
+		{"car beside player", []LaneDef{road}, func(w *World) { place(w, 0, "bus", 320+GridSize) }, Running},
+		{"car in the lane above", []LaneDef{emptyLane(416, LaneRoad, "bus")}, func(w *World) { place(w, 0, "bus", 300) }, Running},
+		{"water without a log", []LaneDef{water}, func(*World) {}, Lost},
+		{"standing on a log", []LaneDef{water}, func(w *World) { place(w, 0, "log", 300) }, Running},
+		{"submerged turtles", []LaneDef{emptyLane(448, LaneWater, "turtles")}, func(w *World) {
+			turtles := place(w, 0, "turtles", 300)
+			turtles.sinkTick = SinkCycleTicks - SinkDownTicks
+			turtles.Submerged = true
+		}, Lost},
+		{"log carries player off screen", []LaneDef{water}, func(w *World) {
+			w.Player.X = 0
+			place(w, 0, "log", -2*GridSize)
+		}, Lost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(Config{Level: testLevel(tt.lanes...), Seed: 1})
+			tt.setup(w)
+			events := w.Step(TickDuration, Input{})
+			if w.Outcome != tt.want {
+				t.Fatalf("outcome = %v, want %v", w.Outcome, tt.want)
+			}
+			if tt.want == Lost && lastEvent(events) != EventHit {
+				t.Errorf("events = %v, want EventHit last", events)
+			}
+		})
+	}
+}
+
+// Река без плотов: в любом режиме игрок тонет, не дойдя до цели
+func TestDrowning(t *testing.T) {
+	l := testLevel()
+	for y := GridSize; y < 448; y += GridSize {
+		l.Lanes = append(l.Lanes, emptyLane(float64(y), LaneWater, "log"))
+	}
+	tests := []struct {
+		name  string
+		mode  MovementMode
+		input func(tick int) Input
+	}{
+		{"free", MoveFree, func(int) Input { return Input{Up: true} }},
+		{"hop holding up", MoveHop, func(int) Input { return Input{Up: true} }},
+		{"hop tapping up", MoveHop, func(tick int) Input { return Input{Up: tick%2 == 1} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(Config{Level: l, Seed: 1, Mode: tt.mode})
+			var events []Event
+			for w.Outcome == Running && w.Tick < TickRate {
+				events = w.Step(TickDuration, tt.input(w.Tick+1))
+			}
+			if w.Outcome != Lost || lastEvent(events) != EventHit {
+				t.Fatalf("outcome %v at tick %d with events %v, want %v with EventHit", w.Outcome, w.Tick, events, Lost)
+			}
+			if w.Player.Y < 416 {
+				t.Errorf("drowned at y %g, want in the first water lane", w.Player.Y)
+			}
+		})
+	}
+}
+
+func TestRaftCarriesPlayer(t *testing.T) {
+	w := NewWorld(Config{Level: testLevel(emptyLane(448, LaneWater, "log")), Seed: 1})
+	log := place(w, 0, "log", 300)
+	x0 := w.Player.X
+	w.Step(TickDuration, Input{})
+	if want := x0 + log.Velocity()*TickDuration; w.Player.X != want {
+		t.Errorf("player X = %g, want %g", w.Player.X, want)
+	}
+}
+
+func TestWin(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     MovementMode
+		input    func(tick int) Input
+		wantTick int
+	}{
+		// 32px со скоростью PlayerSpeed клеток в секунду, до верха цели 27px
+		{"free", MoveFree, func(int) Input { return Input{Up: true} }, 11},
+		// Победа только после приземления, хотя в прыжке игрок уже в зоне цели
+		{"hop", MoveHop, func(tick int) Input { return Input{Up: tick == 1} }, HopTicks + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLevel()
+			l.Start.Y = GridSize
+			w := NewWorld(Config{Level: l, Seed: 1, Mode: tt.mode, Streak: 1})
+			var events []Event
+			for w.Outcome == Running && w.Tick < TickRate {
+				events = w.Step(TickDuration, tt.input(w.Tick+1))
+			}
+			if w.Outcome != Won || w.Tick != tt.wantTick {
+				t.Fatalf("outcome %v at tick %d, want %v at tick %d", w.Outcome, w.Tick, Won, tt.wantTick)
+			}
+			want := []Event{
+				{Kind: EventTimeBonus, Points: l.TimeLimit * PointsPerSecond * MultiplierFor(1), X: w.Player.X, Y: w.Player.Y},
+				{Kind: EventWin, X: w.Player.X, Y: w.Player.Y},
+			}
+			if !reflect.DeepEqual(events, want) {
+				t.Errorf("events = %v, want %v", events, want)
+			}
+		})
+	}
+}
+
+// У края экрана прыжок упирается в те же границы, что и плавное движение
+func TestHopBounds(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         float64
+		in           Input
+		wantX, wantY float64
+	}{
+		{"up into the text area", 320, GridSize, Input{Up: true}, 320, TextAreaHeight},
+		{"left near the edge", 10, 448, Input{Left: true}, 0, 448},
+		{"right at the edge", ScreenWidth - GridSize, 448, Input{Right: true}, ScreenWidth - GridSize, 448},
+		{"down at the bottom", 320, ScreenHeight - GridSize, Input{Down: true}, 320, ScreenHeight - GridSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(Config{Level: testLevel(), Seed: 1, Mode: MoveHop})
+			w.Player.X, w.Player.Y = tt.x, tt.y
+			w.Step(TickDuration, tt.in)
+			for i := 0; i < HopTicks; i++ {
+				w.Step(TickDuration, Input{})
+			}
+			if w.Player.X != tt.wantX || w.Player.Y != tt.wantY {
+				t.Errorf("landed at (%g, %g), want (%g, %g)", w.Player.X, w.Player.Y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestTimeUp(t *testing.T) {
+	l := testLevel()
+	l.TimeLimit = 2
+	w := NewWorld(Config{Level: l, Seed: 1})
+	for i := 1; i < l.TimeLimit*TickRate; i++ {
+		w.Step(TickDuration, Input{})
+		if w.Outcome != Running {
+			t.Fatalf("outcome %v at tick %d, before the time limit", w.Outcome, w.Tick)
+		}
+	}
+	if w.CurrentTime != 1 {
+		t.Errorf("CurrentTime = %d one tick before the end, want 1", w.CurrentTime)
+	}
+	events := w.Step(TickDuration, Input{})
+	if w.Outcome != Lost || lastEvent(events) != EventTimeUp {
+		t.Errorf("outcome %v with events %v, want %v with EventTimeUp", w.Outcome, events, Lost)
+	}
+	if events := w.Step(TickDuration, Input{Up: true}); events != nil || w.Tick != l.TimeLimit*TickRate {
+		t.Errorf("world changed after the end: tick %d, events %v", w.Tick, events)
+	}
+}
+
+func TestLives(t *testing.T) {
+	l := testLevel(emptyLane(448, LaneRoad, "bus"))
+	l.Lives = 2
+	w := NewWorld(Config{Level: l, Seed: 1})
+	bus := place(w, 0, "bus", 300)
+
+	events := w.Step(TickDuration, Input{})
+	if w.Outcome != Running || lastEvent(events) != EventLifeLost || w.Lives != 1 {
+		t.Fatalf("outcome %v, lives %d, events %v, want a lost life", w.Outcome, w.Lives, events)
+	}
+	if w.Player.X != l.Start.X || w.Player.Y != l.Start.Y {
+		t.Errorf("respawned at (%g, %g), want start (%g, %g)", w.Player.X, w.Player.Y, l.Start.X, l.Start.Y)
+	}
+	if w.Invulnerable() != InvulnerableTicks {
+		t.Errorf("Invulnerable() = %d, want %d", w.Invulnerable(), InvulnerableTicks)
+	}
+
+	// Пока игрок неуязвим, машина проезжает сквозь него
+	for i := 0; i < InvulnerableTicks; i++ {
+		bus.X = 300
+		if events := w.Step(TickDuration, Input{}); len(events) > 0 {
+			t.Fatalf("tick %d: events %v while invulnerable", w.Tick, events)
+		}
+	}
+	bus.X = 300
+	events = w.Step(TickDuration, Input{})
+	if w.Outcome != Lost || lastEvent(events) != EventHit {
+		t.Errorf("outcome %v with events %v, want %v with EventHit", w.Outcome, events, Lost)
+	}
+}
+
+func TestRespawnOnMedian(t *testing.T) {
+	// Свободная строка 12 между полосами становится разделительной
+	l := testLevel(emptyLane(416, LaneRoad, "bus"), emptyLane(352, LaneRoad, "bus"))
+	l.Lives = 2
+	w := NewWorld(Config{Level: l, Seed: 1})
+	if tile := w.Map.At(320, 384); tile != TileMedian {
+		t.Fatalf("tile at row 12 is %v, want %v", tile, TileMedian)
+	}
+
+	w.Player.Y = 384
+	w.Step(TickDuration, Input{})
+	w.Player.Y = 352
+	place(w, 1, "bus", 300)
+	if events := w.Step(TickDuration, Input{}); lastEvent(events) != EventLifeLost {
+		t.Fatalf("events = %v, want EventLifeLost", events)
+	}
+	if w.Player.X != 320 || w.Player.Y != 384 {
+		t.Errorf("respawned at (%g, %g), want the median (320, 384)", w.Player.X, w.Player.Y)
+	}
+}
+
+// Прыжки подряд не пропускают разделительную полосу: шаг приземления на ней
+// запоминается, хотя следующий прыжок уже отложен
+func TestHopRecordsMedian(t *testing.T) {
+	l := testLevel(emptyLane(416, LaneRoad, "bus"), emptyLane(352, LaneRoad, "bus"))
+	l.Lives = 2
+	w := NewWorld(Config{Level: l, Seed: 1, Mode: MoveHop})
+	// Нажатие на каждом втором шаге: следующий прыжок всегда уже отложен
+	tap := func() Input { return Input{Up: w.Tick%2 == 0} }
+	for w.Outcome == Running && w.Tick < TickRate && (w.Player.Y > 384 || w.Walking) {
+		w.Step(TickDuration, tap())
+	}
+	place(w, 1, "bus", 300)
+	var events []Event
+	for w.Outcome == Running && w.Tick < TickRate && lastEvent(events) != EventLifeLost {
+		events = w.Step(TickDuration, tap())
+	}
+	if lastEvent(events) != EventLifeLost {
+		t.Fatalf("no life lost hopping into the bus by tick %d", w.Tick)
+	}
+	if w.Player.X != 320 || w.Player.Y != 384 {
+		t.Errorf("respawned at (%g, %g), want the median (320, 384)", w.Player.X, w.Player.Y)
+	}
+}
+
+// busyLevel - уровень с разными полосами дорог и реки для прогонов со случайным вводом
+func busyLevel() *Level {
+	l := testLevel(
+		LaneDef{Y: 32, Kind: LaneWater, Direction: DirRandom, Vehicles: []string{"log", "turtles"}, Count: 3, GapMin: 4, GapMax: 6},
+		LaneDef{Y: 64, Kind: LaneWater, Direction: DirRandom, Vehicle: "log_long", Count: 2, GapMin: 6, GapMax: 8},
+		LaneDef{Y: 128, Direction: DirRandom, Vehicles: []string{"car", "motorcycle"}, Count: 4, GapMin: 3, GapMax: 6},
+		LaneDef{Y: 160, Direction: DirRandom, Vehicle: "truck", Count: 2, GapMin: 5, GapMax: 8},
+		LaneDef{Y: 224, Direction: DirRandom, SpeedMin: 3, SpeedMax: 5, Vehicles: []string{"bus", "car"}, Count: 3, GapMin: 4, GapMax: 7},
+		LaneDef{Y: 288, Direction: DirRandom, Vehicle: "motorcycle", Count: 5, GapMin: 2, GapMax: 4},
+		LaneDef{Y: 352, Direction: DirRandom, Vehicles: []string{"car", "bus", "truck"}, Count: 3, GapMin: 5, GapMax: 8},
+		LaneDef{Y: 416, Direction: DirRandom, Vehicle: "car", Count: 3, GapMin: 3, GapMax: 5},
+	)
+	l.Lives = 3
+	return l
+}
+
+// randomInputs - ввод на n шагов: случайные направления, которые держатся
+// случайное время, чаще вверх, чтобы игрок доходил до реки и цели
+func randomInputs(seed int64, n int) []Input {
+	rng := rand.New(rand.NewSource(seed))
+	inputs := make([]Input, 0, n)
+	for len(inputs) < n {
+		var in Input
+		switch rng.Intn(6) {
+		case 0, 1, 2:
+			in.Up = true
+		case 3:
+			in.Left = true
+		case 4:
+			in.Right = true
+		}
+		for hold := 1 + rng.Intn(20); hold > 0 && len(inputs) < n; hold-- {
+			inputs = append(inputs, in)
+		}
+	}
+	return inputs
+}
+
+// runResult - всё, что видно снаружи после забега
+type runResult struct {
+	Tick     int
+	Outcome  Outcome
+	Score    Score
+	Lives    int
+	X, Y     float64
+	Events   []Event
+	Vehicles []Rect
+}
+
+func run(cfg Config, inputs []Input) runResult {
+	return runWorld(NewWorld(cfg), inputs)
+}
+
+func runWorld(w *World, inputs []Input) runResult {
+	var r runResult
+	for _, in := range inputs {
+		r.Events = append(r.Events, w.Step(TickDuration, in)...)
+	}
+	r.Tick, r.Outcome, r.Score, r.Lives = w.Tick, w.Outcome, w.Score, w.Lives
+	r.X, r.Y = w.Player.X, w.Player.Y
+	for _, c := range append(w.Cars, w.Platforms...) {
+		r.Vehicles = append(r.Vehicles, c.Bounds())
+	}
+	return r
+}
+
+func TestDeterminism(t *testing.T) {
+	for _, mode := range []MovementMode{MoveFree, MoveHop} {
+		t.Run(mode.String(), func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				cfg := Config{Level: busyLevel(), Seed: seed, Mode: mode}
+				inputs := randomInputs(seed, 30*TickRate)
+				a, b := run(cfg, inputs), run(cfg, inputs)
+				if !reflect.DeepEqual(a, b) {
+					t.Fatalf("seed %d: two runs with the same seed and input differ:\n%+v\n%+v", seed, a, b)
+				}
+				cfg.Seed += 1000
+				if c := run(cfg, inputs); reflect.DeepEqual(a.Vehicles, c.Vehicles) {
+					t.Errorf("seeds %d and %d give the same traffic", seed, cfg.Seed)
+				}
+			}
+		})
+	}
+}
+
+func TestNearMiss(t *testing.T) {
+	tests := []struct {
+		name   string
+		lane   LaneDef
+		x      float64 // Где машина при старте; игрок на (320, 448)
+		want   []EventKind
+		points int
+	}{
+		// Машина соседней полосы проезжает мимо
+		{"car passes by", emptyLane(416, LaneRoad, "car"), 400, []EventKind{EventNearMiss}, PointsNearMiss},
+		// Машина въезжает в зону сближения, а потом сбивает: очков нет
+		{"car comes close and hits", emptyLane(448, LaneRoad, "bus"), 400, []EventKind{EventHit}, 0},
+		// Далёкая полоса не в счёт
+		{"car far away", emptyLane(352, LaneRoad, "car"), 400, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(Config{Level: testLevel(tt.lane), Seed: 1})
+			car := place(w, 0, tt.lane.Vehicle, tt.x)
+			band := w.playerCollider().Bounds().Inset(-NearMissDistance)
+			var got []EventKind
+			for w.Outcome == Running && car.X > 0 {
+				for _, ev := range w.Step(TickDuration, Input{}) {
+					got = append(got, ev.Kind)
+					if ev.Kind == EventNearMiss && band.Overlaps(car.Collider().Bounds()) {
+						t.Errorf("near miss at tick %d while the car is still close", w.Tick)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+			if w.Score.Points != tt.points {
+				t.Errorf("score = %d, want %d", w.Score.Points, tt.points)
+			}
+		})
+	}
+}
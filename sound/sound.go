@@ -0,0 +1,13 @@
+// Package sound содержит встроенные музыку и звуковые эффекты игры в WAV
+package sound
+
+import "embed"
+
+//go:embed music_menu.wav music_play.wav music_pause.wav
+//go:embed step.wav horn.wav crash.wav win.wav tick.wav timeup.wav
+var files embed.FS
+
+// Read возвращает содержимое встроенного файла name
+func Read(name string) ([]byte, error) {
+	return files.ReadFile(name)
+}
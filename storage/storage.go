@@ -0,0 +1,131 @@
+// Package storage хранит настройки и сохранения игры в JSON-файлах
+// пользовательского каталога конфигурации. Пакет не зависит от Ebiten,
+// поэтому его можно проверять без окна.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Каталог с настройками и сохранениями внутри пользовательского каталога конфигурации
+const appDirName = "run-boy-run"
+
+// Path возвращает полный путь к файлу name в каталоге игры
+func Path(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName, name), nil
+}
+
+// Load читает файл name из каталога настроек. Если файла нет,
+// возвращает ошибку, для которой errors.Is(err, os.ErrNotExist)
+func Load(name string, v any) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func Save(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return SaveFile(name, data)
+}
+
+// SaveFile атомарно записывает файл в каталог настроек: сначала во временный
+// файл рядом, потом переименованием, чтобы сбой не оставил файл наполовину записанным
+func SaveFile(name string, data []byte) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // После успешного переименования файла уже нет
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadOrQuarantine читает файл name, как Load. Испорченный файл
+// откладывается в сторону; what называет данные файла в журнале.
+// При любой ошибке вызывающий начинает с чистых данных
+func LoadOrQuarantine(name, what string, v any) error {
+	err := Load(name, v)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+		log.Printf("Failed to load %s: %v", what, err)
+		return err
+	}
+	if bad, qerr := quarantine(name); qerr != nil {
+		log.Printf("The %s file is corrupted (%v) and could not be moved aside: %v", what, err, qerr)
+	} else {
+		log.Printf("The %s file is corrupted (%v), moved to %s", what, err, bad)
+	}
+	return err
+}
+
+// quarantine откладывает испорченный файл в сторону, чтобы начать с чистого,
+// не потеряв старые данные. Возвращает новое имя файла
+func quarantine(name string) (string, error) {
+	path, err := Path(name)
+	if err != nil {
+		return "", err
+	}
+	bad := fmt.Sprintf("%s.corrupt-%d", path, time.Now().Unix())
+	return bad, os.Rename(path, bad)
+}
+
+// Migrate переводит данные v версии *version в последнюю версию len(steps):
+// steps[n] переводит версию n в n+1. Данные более новой версии только
+// помечаются последней - незнакомые поля уже отброшены при разборе.
+// Возвращает true, если шаги изменили данные и их стоит сохранить
+func Migrate[T any](what string, v *T, version *int, steps []func(*T)) bool {
+	latest := len(steps)
+	if *version > latest {
+		log.Printf("The %s file version %d is newer than %d, unknown fields are ignored", what, *version, latest)
+		*version = latest
+		return false
+	}
+	*version = max(*version, 0)
+	migrated := *version < latest
+	for ; *version < latest; *version++ {
+		steps[*version](v)
+	}
+	return migrated
+}
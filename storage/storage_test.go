@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tempDir направляет каталог настроек во временный каталог теста
+// и возвращает каталог, где будут лежать файлы игры
+func tempDir(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AppData", dir)
+	t.Setenv("HOME", dir)
+	path, err := Path("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+type settings struct {
+	Version int      `json:"version"`
+	Scale   int      `json:"scale"`
+	Steps   []string `json:"steps,omitempty"`
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := tempDir(t)
+	want := settings{Version: 2, Scale: 3}
+	if err := Save("settings.json", want); err != nil {
+		t.Fatal(err)
+	}
+	var got settings
+	if err := Load("settings.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+	// Временный файл записи не остаётся рядом
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.tmp*")); len(files) > 0 {
+		t.Errorf("temporary files left: %v", files)
+	}
+	if err := Load("missing.json", &got); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of a missing file = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoadOrQuarantine(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string // Пусто - файла нет
+		want       settings
+		wantErr    bool
+		quarantine bool
+	}{
+		{name: "valid", data: `{"version": 1, "scale": 2}`, want: settings{Version: 1, Scale: 2}},
+		{name: "missing", wantErr: true},
+		{name: "syntax error", data: `{"version": 1,`, wantErr: true, quarantine: true},
+		{name: "wrong type", data: `{"version": 1, "scale": "big"}`, want: settings{Version: 1}, wantErr: true, quarantine: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tempDir(t)
+			path := filepath.Join(dir, "settings.json")
+			if tt.data != "" {
+				if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got settings
+			err := LoadOrQuarantine("settings.json", "settings", &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.quarantine && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loaded %+v, want %+v", got, tt.want)
+			}
+			bad, _ := filepath.Glob(path + ".corrupt-*")
+			if quarantined := len(bad) > 0; quarantined != tt.quarantine {
+				t.Errorf("quarantined = %v, want %v", quarantined, tt.quarantine)
+			}
+			if _, err := os.Stat(path); tt.quarantine && err == nil {
+				t.Errorf("corrupted file is still in place")
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	steps := []func(*settings){
+		func(s *settings) { s.Steps = append(s.Steps, "0->1") },
+		func(s *settings) { s.Steps = append(s.Steps, "1->2"); s.Scale *= 2 },
+	}
+	tests := []struct {
+		name         string
+		in           settings
+		want         settings
+		wantMigrated bool
+	}{
+		{"oldest", settings{Scale: 1}, settings{Version: 2, Scale: 2, Steps: []string{"0->1", "1->2"}}, true},
+		{"one behind", settings{Version: 1, Scale: 1}, settings{Version: 2, Scale: 2, Steps: []string{"1->2"}}, true},
+		{"latest", settings{Version: 2, Scale: 1}, settings{Version: 2, Scale: 1}, false},
+		// Файл более новой версии игры читается как последний известный
+		{"newer", settings{Version: 5, Scale: 1}, settings{Version: 2, Scale: 1}, false},
+		{"negative", settings{Version: -1, Scale: 1}, settings{Version: 2, Scale: 2, Steps: []string{"0->1", "1->2"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.in
+			migrated := Migrate("settings", &s, &s.Version, steps)
+			if migrated != tt.wantMigrated || !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("Migrate() = %v, %+v, want %v, %+v", migrated, s, tt.wantMigrated, tt.want)
+			}
+		})
+	}
+}
+
+// Перенос из старого файла - шаг миграции, который читает этот файл
+func TestMigrateFromLegacyFile(t *testing.T) {
+	tempDir(t)
+	if err := Save("legacy.json", map[string]int{"scale": 3}); err != nil {
+		t.Fatal(err)
+	}
+	s := settings{Scale: 1}
+	if err := LoadOrQuarantine("settings.json", "settings", &s); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("error = %v, want os.ErrNotExist", err)
+	}
+	steps := []func(*settings){func(s *settings) {
+		if err := Load("legacy.json", s); err != nil {
+			t.Error(err)
+		}
+	}}
+	if !Migrate("settings", &s, &s.Version, steps) {
+		t.Fatal("Migrate() = false, want true")
+	}
+	if err := Save("settings.json", s); err != nil {
+		t.Fatal(err)
+	}
+	var got settings
+	if err := Load("settings.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (settings{Version: 1, Scale: 3}); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved %+v, want %+v", got, want)
+	}
+}